@@ -2,8 +2,13 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"iter"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -16,6 +21,12 @@ import (
 
 func main() {
 
+	// When invoked with arguments, run as a command line tool instead of
+	// a GitHub Action
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
 	// Determine if we want the action to fail on error, or be silent to
 	// prevent blocking CI pipelines
 	failCode := 0
@@ -26,7 +37,8 @@ func main() {
 		log.Printf("INPUT_FAIL_ON_ERROR enabled, the action will exit with an error code on failure")
 		failCode = 1
 	}
-	gh, err := getGithubClient()
+	transport := newRateLimitTransport(nil)
+	gh, err := getGithubClient(transport)
 	if err != nil {
 		log.Printf("Failed to retrieve a GitHub client: %+v", err)
 		os.Exit(failCode)
@@ -73,6 +85,20 @@ func main() {
 
 	}
 
+	problems := labeler.ValidateConfig(*configRaw)
+	for _, problem := range problems {
+		// Report as annotations so that they are visible in the
+		// workflow summary
+		fmt.Printf("::error file=%s,line=%d,col=%d::%s\n",
+			configFile, problem.Line, problem.Column, problem.Message)
+	}
+	if len(problems) > 0 {
+		log.Printf("Found %d problems in the configuration", len(problems))
+		if failCode != 0 {
+			os.Exit(failCode)
+		}
+	}
+
 	config, err := getLabelerConfigV1(configRaw)
 	if err != nil {
 		log.Printf("Unable to parse configuration")
@@ -87,17 +113,142 @@ func main() {
 
 	l := newLabeler(gh, config)
 
+	// Determine if the user only wants to see the changes to labels
+	// without applying them
+	dryRun, err := strconv.ParseBool(os.Getenv("INPUT_DRY_RUN"))
+	if err == nil && dryRun {
+		log.Printf("INPUT_DRY_RUN enabled, labels will not be modified")
+		l.DryRun = true
+	}
+
+	// Determine if the user wants an explanation of the labels
+	explain, _ := strconv.ParseBool(os.Getenv("INPUT_EXPLAIN"))
+	explainComment, _ := strconv.ParseBool(os.Getenv("INPUT_EXPLAIN_COMMENT"))
+	if l.DryRun || explain || explainComment {
+		l.ReportPlan = func(plan *labeler.LabelPlan) {
+			if l.DryRun || explain {
+				printPlan(plan)
+			}
+			if explainComment && l.DryRun {
+				log.Printf("Dry run, not commenting the explanation on #%d", plan.IssueNo)
+			} else if explainComment {
+				err := upsertExplanationComment(gh, plan)
+				if err != nil {
+					log.Printf("Unable to comment the explanation on #%d: %+v", plan.IssueNo, err)
+				}
+			}
+		}
+	}
+
+	// Determine how many PRs or issues to process in parallel on
+	// scheduled executions
+	if concurrency := os.Getenv("INPUT_CONCURRENCY"); concurrency != "" {
+		l.Concurrency, err = strconv.Atoi(concurrency)
+		if err != nil {
+			log.Printf("INPUT_CONCURRENCY must be a number, got %q: processing targets serially", concurrency)
+			l.Concurrency = 1
+		}
+	}
+
+	// Ignore the check runs of this workflow run, which are in progress
+	// while the labeler runs
+	if runID := os.Getenv("GITHUB_RUN_ID"); runID != "" {
+		l.WorkflowRunID, err = strconv.ParseInt(runID, 10, 64)
+		if err != nil {
+			log.Printf("GITHUB_RUN_ID must be a number, got %q", runID)
+		}
+	}
+
+	// Determine if the user wants the repository labels to match the
+	// colors and descriptions in the config
+	syncLabels, _ := strconv.ParseBool(os.Getenv("INPUT_SYNC_LABELS"))
+	reportUnreferenced, _ := strconv.ParseBool(os.Getenv("INPUT_REPORT_UNREFERENCED_LABELS"))
+	if syncLabels {
+		t := strings.Split(os.Getenv("GITHUB_REPOSITORY"), "/")
+		report, err := l.SyncLabels(t[0], t[1])
+		if err != nil {
+			log.Printf("Unable to sync labels: %+v", err)
+		} else if reportUnreferenced && len(report.Unreferenced) > 0 {
+			log.Printf("Labels not referenced in the configuration: %s",
+				strings.Join(report.Unreferenced, ", "))
+		}
+	}
+
 	if eventName == "schedule" {
 		t := strings.Split(os.Getenv("GITHUB_REPOSITORY"), "/")
 		owner, repo := t[0], t[1]
-		l.ProcessAllPRs(owner, repo)
-		l.ProcessAllIssues(owner, repo)
-	} else {
-		err = l.HandleEvent(eventName, eventPayload)
+		prs, prsErr := l.ProcessAllPRs(owner, repo)
+		issues, issuesErr := l.ProcessAllIssues(owner, repo)
+		failed := countFailed(prs) + countFailed(issues)
+		transport.logQuota()
+		if prsErr != nil || issuesErr != nil || failed > 0 {
+			log.Printf("Scheduled execution finished with errors, %d targets failed", failed)
+			os.Exit(failCode)
+		}
+		return
+	}
+
+	err = l.HandleEvent(eventName, eventPayload)
+	if err != nil {
+		log.Printf("Unable to execute action: %+v", err)
+	}
+
+	transport.logQuota()
+}
+
+// countFailed returns the number of targets that could not be processed
+func countFailed(results []labeler.TargetResult) int {
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// printPlan writes the plan to stdout as a single line of JSON so that it
+// can be easily consumed by other tools
+func printPlan(plan *labeler.LabelPlan) {
+	raw, err := json.Marshal(plan)
+	if err != nil {
+		log.Printf("Unable to serialize plan %+v: %s", plan, err)
+		return
+	}
+	fmt.Println(string(raw))
+}
+
+// upsertExplanationComment posts the explanation of the plan as a
+// comment in the PR or issue, updating the one from previous executions
+// if it exists so that we don't flood the conversation
+func upsertExplanationComment(gh *github.Client, plan *labeler.LabelPlan) error {
+	ctx := context.Background()
+	body := labeler.FormatExplanation(plan)
+
+	comments := paginate(func(opts github.ListOptions) ([]*github.IssueComment, *github.Response, error) {
+		return gh.Issues.ListComments(ctx,
+			plan.Owner, plan.Repo, plan.IssueNo,
+			&github.IssueListCommentsOptions{ListOptions: opts})
+	})
+	for comment, err := range comments {
 		if err != nil {
-			log.Printf("Unable to execute action: %+v", err)
+			return err
+		}
+		if strings.Contains(comment.GetBody(), labeler.ExplanationMarker) {
+			if comment.GetBody() == body {
+				return nil // nothing changed
+			}
+			_, _, err = gh.Issues.EditComment(ctx,
+				plan.Owner, plan.Repo, comment.GetID(),
+				&github.IssueComment{Body: &body})
+			return err
 		}
 	}
+
+	_, _, err := gh.Issues.CreateComment(ctx,
+		plan.Owner, plan.Repo, plan.IssueNo,
+		&github.IssueComment{Body: &body})
+	return err
 }
 
 func getRepoFile(gh *github.Client, repo, file, sha string) (*[]byte, error) {
@@ -168,10 +319,13 @@ func getLabelerConfigV0(configRaw *[]byte) (labeler.LabelerConfigV1, error) {
 	}, err
 }
 
-func getGithubClient() (*github.Client, error) {
+// getGithubClient returns a client authenticated with GITHUB_TOKEN that
+// sends requests through the given transport
+func getGithubClient(transport http.RoundTripper) (*github.Client, error) {
 	ghToken := os.Getenv("GITHUB_TOKEN")
 	ghApiHost := os.Getenv("GITHUB_API_HOST")
-	ctx := context.Background()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient,
+		&http.Client{Transport: transport})
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: ghToken},
 	)
@@ -209,17 +363,35 @@ func newLabeler(gh *github.Client, config *labeler.LabelerConfigV1) *labeler.Lab
 			return config, nil
 		},
 
-		ReplaceLabels: func(target *labeler.Target, labels []string) error {
-			log.Printf("Setting labels to %s/%s#%d: %s", target.Owner, target.RepoName, target.IssueNo, labels)
-			_, _, err := gh.Issues.ReplaceLabelsForIssue(
-				context.Background(), target.Owner, target.RepoName, target.IssueNo, labels)
+		AddLabels: func(target *labeler.Target, labels []string) error {
+			log.Printf("Adding labels to %s/%s#%d: %s", target.Owner, target.RepoName, target.IssueNo, labels)
+			_, _, err := gh.Issues.AddLabelsToIssue(
+				ctx, target.Owner, target.RepoName, target.IssueNo, labels)
 			return err
 		},
 
+		RemoveLabels: func(target *labeler.Target, labels []string) error {
+			log.Printf("Removing labels from %s/%s#%d: %s", target.Owner, target.RepoName, target.IssueNo, labels)
+			for _, label := range labels {
+				// go-github doesn't escape the label name in the URL
+				resp, err := gh.Issues.RemoveLabelForIssue(
+					ctx, target.Owner, target.RepoName, target.IssueNo, url.PathEscape(label))
+				if resp != nil && resp.StatusCode == http.StatusNotFound {
+					// Someone else removed it already
+					continue
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+
 		GetCurrentLabels: func(target *labeler.Target) ([]string, error) {
-			opts := github.ListOptions{} // TODO: ignoring pagination here
-			currLabels, _, err := gh.Issues.ListLabelsByIssue(
-				context.Background(), target.Owner, target.RepoName, target.IssueNo, &opts)
+			currLabels, err := collect(paginate(func(opts github.ListOptions) ([]*github.Label, *github.Response, error) {
+				return gh.Issues.ListLabelsByIssue(ctx,
+					target.Owner, target.RepoName, target.IssueNo, &opts)
+			}))
 
 			labels := []string{}
 			for _, label := range currLabels {
@@ -227,6 +399,8 @@ func newLabeler(gh *github.Client, config *labeler.LabelerConfigV1) *labeler.Lab
 			}
 			return labels, err
 		},
+		// Shares the authentication and retries of the GitHub client
+		Client: gh.Client(),
 		GitHubFacade: &labeler.GitHubFacade{
 			GetRawDiff: func(owner, repo string, prNumber int) (string, error) {
 				diff, _, err := gh.PullRequests.GetRaw(ctx,
@@ -238,19 +412,96 @@ func newLabeler(gh *github.Client, config *labeler.LabelerConfigV1) *labeler.Lab
 				pr, _, err := gh.PullRequests.Get(ctx, owner, repo, prNumber)
 				return pr, err
 			},
-			ListIssuesByRepo: func(owner, repo string) ([]*github.Issue, error) {
-				issues, _, err := gh.Issues.ListByRepo(ctx,
-					owner, repo, &github.IssueListByRepoOptions{
-						State: "open",
-					})
-				return issues, err
+			GetIssue: func(owner, repo string, issueNo int) (*github.Issue, error) {
+				issue, _, err := gh.Issues.Get(ctx, owner, repo, issueNo)
+				return issue, err
+			},
+			GetFileContent: func(owner, repo, path, ref string) (string, error) {
+				file, _, resp, err := gh.Repositories.GetContents(ctx, owner, repo, path,
+					&github.RepositoryContentGetOptions{Ref: ref})
+				if resp != nil && resp.StatusCode == http.StatusNotFound {
+					return "", labeler.ErrFileNotFound
+				}
+				if err != nil {
+					return "", err
+				}
+				if file == nil {
+					return "", fmt.Errorf("%s is not a file", path)
+				}
+				return file.GetContent()
+			},
+			ListIssuesByRepo: func(owner, repo string) iter.Seq2[*github.Issue, error] {
+				return paginate(func(opts github.ListOptions) ([]*github.Issue, *github.Response, error) {
+					return gh.Issues.ListByRepo(ctx,
+						owner, repo, &github.IssueListByRepoOptions{
+							State:       "open",
+							ListOptions: opts,
+						})
+				})
+			},
+			ListPRs: func(owner, repo string) iter.Seq2[*github.PullRequest, error] {
+				return paginate(func(opts github.ListOptions) ([]*github.PullRequest, *github.Response, error) {
+					return gh.PullRequests.List(ctx,
+						owner, repo, &github.PullRequestListOptions{
+							State:       "open",
+							ListOptions: opts,
+						})
+				})
+			},
+			ListReviews: func(owner, repo string, prNumber int) iter.Seq2[*github.PullRequestReview, error] {
+				return paginate(func(opts github.ListOptions) ([]*github.PullRequestReview, *github.Response, error) {
+					return gh.PullRequests.ListReviews(ctx,
+						owner, repo, prNumber, &opts)
+				})
+			},
+			ListCommits: func(owner, repo string, prNumber int) iter.Seq2[*github.RepositoryCommit, error] {
+				return paginate(func(opts github.ListOptions) ([]*github.RepositoryCommit, *github.Response, error) {
+					return gh.PullRequests.ListCommits(ctx, owner, repo, prNumber, &opts)
+				})
+			},
+			ListRequestedReviewers: func(owner, repo string, prNumber int) (*github.Reviewers, error) {
+				reviewers, _, err := gh.PullRequests.ListReviewers(ctx,
+					owner, repo, prNumber, &github.ListOptions{PerPage: perPage})
+				return reviewers, err
+			},
+			ListStatuses: func(owner, repo, ref string) iter.Seq2[*github.RepoStatus, error] {
+				return paginate(func(opts github.ListOptions) ([]*github.RepoStatus, *github.Response, error) {
+					status, resp, err := gh.Repositories.GetCombinedStatus(ctx, owner, repo, ref, &opts)
+					if err != nil {
+						return nil, resp, err
+					}
+					return status.Statuses, resp, nil
+				})
+			},
+			ListCheckRuns: func(owner, repo, ref string) iter.Seq2[*github.CheckRun, error] {
+				return paginate(func(opts github.ListOptions) ([]*github.CheckRun, *github.Response, error) {
+					runs, resp, err := gh.Checks.ListCheckRunsForRef(ctx, owner, repo, ref,
+						&github.ListCheckRunsOptions{ListOptions: opts})
+					if err != nil {
+						return nil, resp, err
+					}
+					return runs.CheckRuns, resp, nil
+				})
+			},
+			ListPRsForCommit: func(owner, repo, sha string) iter.Seq2[*github.PullRequest, error] {
+				return paginate(func(opts github.ListOptions) ([]*github.PullRequest, *github.Response, error) {
+					return gh.PullRequests.ListPullRequestsWithCommit(ctx, owner, repo, sha,
+						&github.PullRequestListOptions{ListOptions: opts})
+				})
+			},
+			ListRepoLabels: func(owner, repo string) iter.Seq2[*github.Label, error] {
+				return paginate(func(opts github.ListOptions) ([]*github.Label, *github.Response, error) {
+					return gh.Issues.ListLabels(ctx, owner, repo, &opts)
+				})
+			},
+			CreateLabel: func(owner, repo string, label *github.Label) error {
+				_, _, err := gh.Issues.CreateLabel(ctx, owner, repo, label)
+				return err
 			},
-			ListPRs: func(owner, repo string) ([]*github.PullRequest, error) {
-				prs, _, err := gh.PullRequests.List(ctx,
-					owner, repo, &github.PullRequestListOptions{
-						State: "open",
-					})
-				return prs, err
+			EditLabel: func(owner, repo, name string, label *github.Label) error {
+				// go-github doesn't escape the label name in the URL
+				_, _, err := gh.Issues.EditLabel(ctx, owner, repo, url.PathEscape(name), label)
+				return err
 			},
 			IsUserMemberOfTeam: func(org, user, team string) (bool, error) {
 				membership, _, err := gh.Teams.GetTeamMembershipBySlug(ctx, org, team, user)
@@ -260,7 +511,6 @@ func newLabeler(gh *github.Client, config *labeler.LabelerConfigV1) *labeler.Lab
 				return membership.GetState() == "active", nil
 			},
 		},
-		Client: labeler.NewDefaultHttpClient(),
 	}
 	return &l
 }
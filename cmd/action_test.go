@@ -2,11 +2,15 @@ package main
 
 import (
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"reflect"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v50/github"
 	l "github.com/srvaroa/labeler/pkg"
 	labeler "github.com/srvaroa/labeler/pkg"
 )
@@ -122,10 +126,10 @@ func TestGetLabelerConfigV1(t *testing.T) {
 			},
 			{
 				Label: "TestFileMatch",
-				Files: []string{
+				Files: &l.FilesConfig{Any: []string{
 					"cmd\\/.*.go",
 					"pkg\\/.*.go",
-				},
+				}},
 			},
 			{
 				Label: "Test",
@@ -273,7 +277,7 @@ func TestGetLabelerConfig2V1(t *testing.T) {
 		},
 		"TestFileMatch": {
 			Label: "TestFileMatch",
-			Files: []string{"cmd\\/.*.go", "pkg\\/.*.go"},
+			Files: &l.FilesConfig{Any: []string{"cmd\\/.*.go", "pkg\\/.*.go"}},
 		},
 		"TestTypePullRequest": {
 			Label: "TestTypePullRequest",
@@ -301,3 +305,189 @@ func TestGetLabelerConfig2V1(t *testing.T) {
 	}
 
 }
+
+func TestGetLabelerConfigV1WithNestedMatchers(t *testing.T) {
+
+	file, err := os.Open("../test_data/config_v1_nested.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := ioutil.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var c *l.LabelerConfigV1
+	c, err = getLabelerConfigV1(&contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := l.LabelerConfigV1{
+		Version: 1,
+		Labels: []l.LabelMatcher{
+			{
+				Label: "docs",
+				Files: &l.FilesConfig{Any: []string{"^docs/.*"}},
+				Any: []l.LabelMatcher{
+					{AuthorInTeam: "docs-team"},
+					{Title: "^\\[docs\\]"},
+				},
+			},
+			{
+				Label: "needs-review",
+				Not: &l.LabelMatcher{
+					All: []l.LabelMatcher{
+						{Draft: "True"},
+						{Negate: true, Authors: []string{"bot"}},
+					},
+				},
+			},
+		},
+	}
+
+	if !cmp.Equal(expect, *c) {
+		t.Fatalf("Expect: %+v Got: %+v", expect, c)
+	}
+}
+
+func TestGetLabelerConfigV1WithAssignees(t *testing.T) {
+
+	file, err := os.Open("../test_data/config_v1_assignees.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := ioutil.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var c *l.LabelerConfigV1
+	c, err = getLabelerConfigV1(&contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := l.LabelerConfigV1{
+		Version: 1,
+		Labels: []l.LabelMatcher{
+			{
+				Label:     "alice",
+				Assignees: &l.AssigneesConfig{Any: []string{"alice", "bob"}},
+			},
+			{
+				Label:     "backend",
+				Milestone: "^v2",
+				Assignees: &l.AssigneesConfig{None: []string{"carol"}, Team: "backend"},
+			},
+			{
+				Label:       "unassigned",
+				HasAssignee: "False",
+			},
+		},
+	}
+
+	if !cmp.Equal(expect, *c) {
+		t.Fatalf("Expect: %+v Got: %+v", expect, c)
+	}
+}
+
+func TestGetLabelerConfigV1WithFilesModes(t *testing.T) {
+
+	file, err := os.Open("../test_data/config_v1_files.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := ioutil.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var c *l.LabelerConfigV1
+	c, err = getLabelerConfigV1(&contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := l.LabelerConfigV1{
+		Version: 1,
+		Labels: []l.LabelMatcher{
+			{
+				Label: "code",
+				Files: &l.FilesConfig{Any: []string{"\\.go$"}},
+			},
+			{
+				Label: "docs-only",
+				Files: &l.FilesConfig{All: []string{"glob:docs/"}},
+			},
+			{
+				Label: "migration",
+				Files: &l.FilesConfig{
+					Any:    []string{"glob:migrations/*.sql"},
+					None:   []string{"glob:src/"},
+					Status: []string{"added"},
+				},
+			},
+		},
+	}
+
+	if !cmp.Equal(expect, *c) {
+		t.Fatalf("Expect: %+v Got: %+v", expect, c)
+	}
+}
+
+func TestRemoveLabelsEscapesNames(t *testing.T) {
+	paths := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	gh := github.NewClient(nil)
+	gh.BaseURL, _ = url.Parse(server.URL + "/")
+
+	l := newLabeler(gh, &labeler.LabelerConfigV1{})
+	target := &labeler.Target{Owner: "srvaroa", RepoName: "labeler", IssueNo: 1}
+	err := l.RemoveLabels(target, []string{"size/S", "a?b", "c#d", "100%"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []string{
+		"/repos/srvaroa/labeler/issues/1/labels/size%2FS",
+		"/repos/srvaroa/labeler/issues/1/labels/a%3Fb",
+		"/repos/srvaroa/labeler/issues/1/labels/c%23d",
+		"/repos/srvaroa/labeler/issues/1/labels/100%25",
+	}
+	if !reflect.DeepEqual(expect, paths) {
+		t.Fatalf("Expect paths: %+v Got: %+v", expect, paths)
+	}
+}
+
+func TestEditLabelEscapesNames(t *testing.T) {
+	paths := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	gh := github.NewClient(nil)
+	gh.BaseURL, _ = url.Parse(server.URL + "/")
+
+	l := newLabeler(gh, &labeler.LabelerConfigV1{})
+	err := l.GitHubFacade.EditLabel("srvaroa", "labeler", "@type/bug",
+		&github.Label{Color: github.String("d73a4a")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []string{"/repos/srvaroa/labeler/labels/@type%2Fbug"}
+	if !reflect.DeepEqual(expect, paths) {
+		t.Fatalf("Expect paths: %+v Got: %+v", expect, paths)
+	}
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"iter"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/go-yaml/yaml"
+	"github.com/google/go-github/v50/github"
+	labeler "github.com/srvaroa/labeler/pkg"
+)
+
+// commands that can be run when the binary receives arguments, which
+// allows using the labeler from the command line outside of GitHub
+// Actions.
+var commands = map[string]func(args []string, stdout io.Writer) error{
+	"eval":        evalCommand,
+	"sync-labels": syncLabelsCommand,
+	"validate":    validateCommand,
+}
+
+// runCommand runs the command named in the first argument and returns
+// the exit code for the process.
+func runCommand(args []string, stdout, stderr io.Writer) int {
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "Unknown command %q\n\n", args[0])
+		printUsage(stderr)
+		return 2
+	}
+	if err := command(args[1:], stdout); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintf(stderr, "Error: %s\n", err)
+		}
+		return 1
+	}
+	return 0
+}
+
+func printUsage(w io.Writer) {
+	names := []string{}
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(w, "Usage: labeler <command> [flags]\n\n")
+	fmt.Fprintf(w, "Available commands: %s\n", strings.Join(names, ", "))
+	fmt.Fprintf(w, "Run labeler <command> -h for the flags of each command.\n")
+	fmt.Fprintf(w, "Without a command, labeler runs as a GitHub Action.\n")
+}
+
+// evalCommand evaluates a configuration against an event payload read
+// from local files, and prints the labels that each matcher produces.
+// All calls to GitHub are replaced by the fixtures given in the flags.
+func evalCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("eval", flag.ContinueOnError)
+	configPath := flags.String("config", ".github/labeler.yml", "path to the labeler configuration")
+	eventName := flags.String("event", "pull_request", "name of the GitHub event that produced the payload")
+	payloadPath := flags.String("payload", "", "path to the JSON payload of the event")
+	diffPath := flags.String("diff", "", "path to the diff of the pull request, used by files and size conditions")
+	teamsPath := flags.String("teams", "", "path to a YAML file mapping team slugs to a list of members")
+	currLabels := flags.String("labels", "", "comma separated list of labels currently set on the PR or issue")
+	asJson := flags.Bool("json", false, "print results as JSON")
+	explain := flags.Bool("explain", false, "explain the result of each condition in the matchers")
+	verbose := flags.Bool("verbose", false, "print the evaluation log")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *payloadPath == "" {
+		return fmt.Errorf("--payload is required")
+	}
+	if !*verbose {
+		log.SetOutput(ioutil.Discard)
+		defer log.SetOutput(os.Stderr)
+	}
+
+	configRaw, err := ioutil.ReadFile(*configPath)
+	if err != nil {
+		return err
+	}
+	config, err := getLabelerConfigV1(&configRaw)
+	if err != nil {
+		return fmt.Errorf("unable to parse configuration %s: %s", *configPath, err)
+	}
+
+	payload, err := ioutil.ReadFile(*payloadPath)
+	if err != nil {
+		return err
+	}
+
+	fixtures := evalFixtures{
+		labels: splitList(*currLabels),
+		teams:  map[string][]string{},
+	}
+	if *diffPath != "" {
+		diff, err := ioutil.ReadFile(*diffPath)
+		if err != nil {
+			return err
+		}
+		fixtures.diff = string(diff)
+		fixtures.hasDiff = true
+	}
+	if *teamsPath != "" {
+		teamsRaw, err := ioutil.ReadFile(*teamsPath)
+		if err != nil {
+			return err
+		}
+		if err = yaml.Unmarshal(teamsRaw, &fixtures.teams); err != nil {
+			return fmt.Errorf("unable to parse teams %s: %s", *teamsPath, err)
+		}
+	}
+
+	plans := []*labeler.LabelPlan{}
+	l := newEvalLabeler(config, &fixtures)
+	l.ReportPlan = func(plan *labeler.LabelPlan) {
+		plans = append(plans, plan)
+	}
+
+	if err = l.HandleEvent(*eventName, &payload); err != nil {
+		return err
+	}
+
+	if *asJson {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(plans)
+	}
+
+	if len(plans) == 0 {
+		fmt.Fprintln(stdout, "No PR or issue was evaluated for this event")
+	}
+	for _, plan := range plans {
+		if *explain {
+			fmt.Fprintf(stdout, "%s/%s#%d\n", plan.Owner, plan.Repo, plan.IssueNo)
+			fmt.Fprint(stdout, labeler.FormatExplanation(plan))
+		} else {
+			printPlanSummary(stdout, plan)
+		}
+	}
+	return nil
+}
+
+// validateCommand strictly validates a configuration file and prints all
+// the problems found in it
+func validateCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	configPath := flags.String("config", ".github/labeler.yml", "path to the labeler configuration")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	configRaw, err := ioutil.ReadFile(*configPath)
+	if err != nil {
+		return err
+	}
+
+	problems := labeler.ValidateConfig(configRaw)
+	for _, problem := range problems {
+		fmt.Fprintf(stdout, "%s:%s\n", *configPath, problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("found %d problems in %s", len(problems), *configPath)
+	}
+	fmt.Fprintf(stdout, "%s is valid\n", *configPath)
+	return nil
+}
+
+// syncLabelsCommand creates or updates the labels of a repository to
+// match the colors and descriptions in the configuration
+func syncLabelsCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("sync-labels", flag.ContinueOnError)
+	configPath := flags.String("config", ".github/labeler.yml", "path to the labeler configuration")
+	repository := flags.String("repo", os.Getenv("GITHUB_REPOSITORY"), "repository to sync, as owner/name")
+	dryRun := flags.Bool("dry-run", false, "print the changes without modifying any label")
+	reportUnreferenced := flags.Bool("report-unreferenced", false, "print the labels of the repository not referenced in the configuration")
+	asJson := flags.Bool("json", false, "print results as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	t := strings.Split(*repository, "/")
+	if len(t) != 2 || t[0] == "" || t[1] == "" {
+		return fmt.Errorf("--repo is required as owner/name")
+	}
+	owner, repo := t[0], t[1]
+
+	configRaw, err := ioutil.ReadFile(*configPath)
+	if err != nil {
+		return err
+	}
+	config, err := getLabelerConfigV1(&configRaw)
+	if err != nil {
+		return fmt.Errorf("unable to parse configuration %s: %s", *configPath, err)
+	}
+
+	transport := newRateLimitTransport(nil)
+	gh, err := getGithubClient(transport)
+	if err != nil {
+		return err
+	}
+	l := newLabeler(gh, config)
+	l.DryRun = *dryRun
+
+	report, err := l.SyncLabels(owner, repo)
+	if err != nil {
+		return err
+	}
+	if !*reportUnreferenced {
+		report.Unreferenced = nil
+	}
+
+	if *asJson {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	printSyncReport(stdout, report, *dryRun)
+	return nil
+}
+
+func printSyncReport(w io.Writer, report *labeler.LabelSyncReport, dryRun bool) {
+	created, updated := "Created", "Updated"
+	if dryRun {
+		created, updated = "Would create", "Would update"
+	}
+	for _, label := range report.Created {
+		fmt.Fprintf(w, "%s %s (color: %s, description: %q)\n",
+			created, label.Name, label.Color, label.Description)
+	}
+	for _, label := range report.Updated {
+		fmt.Fprintf(w, "%s %s (color: %s, description: %q)\n",
+			updated, label.Name, label.Color, label.Description)
+	}
+	if len(report.Created) == 0 && len(report.Updated) == 0 {
+		fmt.Fprintln(w, "Labels are up to date")
+	}
+	if len(report.Unreferenced) > 0 {
+		fmt.Fprintf(w, "Labels not referenced in the configuration: %s\n",
+			strings.Join(report.Unreferenced, ", "))
+	}
+}
+
+func printPlanSummary(w io.Writer, plan *labeler.LabelPlan) {
+	fmt.Fprintf(w, "%s/%s#%d\n", plan.Owner, plan.Repo, plan.IssueNo)
+	for _, m := range plan.Matchers {
+		result := "not evaluated"
+		if m.Evaluated && m.Matched {
+			result = "matched"
+		} else if m.Evaluated {
+			result = "not matched"
+		}
+		fmt.Fprintf(w, "  %s: %s\n", m.Label, result)
+	}
+	fmt.Fprintf(w, "Labels to add: %s\n", strings.Join(plan.Add, ", "))
+	fmt.Fprintf(w, "Labels to remove: %s\n", strings.Join(plan.Remove, ", "))
+}
+
+func splitList(s string) []string {
+	items := []string{}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// evalFixtures holds the local data that replaces calls to GitHub when
+// evaluating a configuration from the command line
+type evalFixtures struct {
+	diff    string
+	hasDiff bool
+	labels  []string
+	teams   map[string][]string
+}
+
+func newEvalLabeler(config *labeler.LabelerConfigV1, fixtures *evalFixtures) *labeler.Labeler {
+	return &labeler.Labeler{
+		FetchRepoConfig: func() (*labeler.LabelerConfigV1, error) {
+			return config, nil
+		},
+		GetCurrentLabels: func(target *labeler.Target) ([]string, error) {
+			return fixtures.labels, nil
+		},
+		AddLabels: func(target *labeler.Target, labels []string) error {
+			return fmt.Errorf("labels can't be modified when evaluating locally")
+		},
+		RemoveLabels: func(target *labeler.Target, labels []string) error {
+			return fmt.Errorf("labels can't be modified when evaluating locally")
+		},
+		DryRun: true,
+		GitHubFacade: &labeler.GitHubFacade{
+			GetRawDiff: func(owner, repo string, prNumber int) (string, error) {
+				if !fixtures.hasDiff {
+					return "", fmt.Errorf("no diff available, use --diff to provide one")
+				}
+				return fixtures.diff, nil
+			},
+			GetPR: func(owner, repo string, prNumber int) (*github.PullRequest, error) {
+				return nil, fmt.Errorf("fetching PRs is not supported when evaluating locally")
+			},
+			GetIssue: func(owner, repo string, issueNo int) (*github.Issue, error) {
+				return nil, fmt.Errorf("fetching issues is not supported when evaluating locally")
+			},
+			GetFileContent: func(owner, repo, path, ref string) (string, error) {
+				return "", fmt.Errorf("fetching files is not supported when evaluating locally")
+			},
+			ListIssuesByRepo: func(owner, repo string) iter.Seq2[*github.Issue, error] {
+				return func(yield func(*github.Issue, error) bool) {
+					yield(nil, fmt.Errorf("listing issues is not supported when evaluating locally"))
+				}
+			},
+			ListPRs: func(owner, repo string) iter.Seq2[*github.PullRequest, error] {
+				return func(yield func(*github.PullRequest, error) bool) {
+					yield(nil, fmt.Errorf("listing PRs is not supported when evaluating locally"))
+				}
+			},
+			ListReviews: func(owner, repo string, prNumber int) iter.Seq2[*github.PullRequestReview, error] {
+				return func(yield func(*github.PullRequestReview, error) bool) {
+					yield(nil, fmt.Errorf("listing reviews is not supported when evaluating locally"))
+				}
+			},
+			ListCommits: func(owner, repo string, prNumber int) iter.Seq2[*github.RepositoryCommit, error] {
+				return func(yield func(*github.RepositoryCommit, error) bool) {
+					yield(nil, fmt.Errorf("listing commits is not supported when evaluating locally"))
+				}
+			},
+			ListRequestedReviewers: func(owner, repo string, prNumber int) (*github.Reviewers, error) {
+				return nil, fmt.Errorf("listing requested reviewers is not supported when evaluating locally")
+			},
+			ListStatuses: func(owner, repo, ref string) iter.Seq2[*github.RepoStatus, error] {
+				return func(yield func(*github.RepoStatus, error) bool) {
+					yield(nil, fmt.Errorf("listing statuses is not supported when evaluating locally"))
+				}
+			},
+			ListCheckRuns: func(owner, repo, ref string) iter.Seq2[*github.CheckRun, error] {
+				return func(yield func(*github.CheckRun, error) bool) {
+					yield(nil, fmt.Errorf("listing check runs is not supported when evaluating locally"))
+				}
+			},
+			ListPRsForCommit: func(owner, repo, sha string) iter.Seq2[*github.PullRequest, error] {
+				return func(yield func(*github.PullRequest, error) bool) {
+					yield(nil, fmt.Errorf("listing PRs is not supported when evaluating locally"))
+				}
+			},
+			ListRepoLabels: func(owner, repo string) iter.Seq2[*github.Label, error] {
+				return func(yield func(*github.Label, error) bool) {
+					yield(nil, fmt.Errorf("listing labels is not supported when evaluating locally"))
+				}
+			},
+			CreateLabel: func(owner, repo string, label *github.Label) error {
+				return fmt.Errorf("labels can't be modified when evaluating locally")
+			},
+			EditLabel: func(owner, repo, name string, label *github.Label) error {
+				return fmt.Errorf("labels can't be modified when evaluating locally")
+			},
+			IsUserMemberOfTeam: func(org, user, team string) (bool, error) {
+				for _, member := range fixtures.teams[team] {
+					if strings.EqualFold(member, user) {
+						return true, nil
+					}
+				}
+				return false, nil
+			},
+		},
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	labeler "github.com/srvaroa/labeler/pkg"
+)
+
+func TestEvalCommand(t *testing.T) {
+
+	var stdout bytes.Buffer
+	err := evalCommand([]string{
+		"--config", "../test_data/config_v1.yml",
+		"--event", "pull_request",
+		"--payload", "../test_data/create_pr_payload",
+		"--diff", "../test_data/diff_response",
+		"--teams", "../test_data/teams.yml",
+		"--labels", "S,Other",
+		"--json",
+	}, &stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var plans []labeler.LabelPlan
+	if err = json.Unmarshal(stdout.Bytes(), &plans); err != nil {
+		t.Fatalf("Unable to parse output %s: %s", stdout.String(), err)
+	}
+
+	expect := []labeler.LabelPlan{
+		{
+			Owner:   "srvaroa",
+			Repo:    "jsonrouter",
+			IssueNo: 2,
+			Add:     []string{"TestAuthorCanMerge", "TestFileMatch", "TestIsAuthorInTeam", "WIP"},
+			Remove:  []string{"S"},
+			Matchers: []labeler.MatcherResult{
+				{Label: "WIP", Evaluated: true, Matched: false},
+				{Label: "WIP", Evaluated: true, Matched: true},
+				{Label: "WOP", Evaluated: true, Matched: false},
+				{Label: "S", Evaluated: true, Matched: false},
+				{Label: "M", Evaluated: true, Matched: false},
+				{Label: "L", Evaluated: true, Matched: false},
+				{Label: "TestFileMatch", Evaluated: true, Matched: true},
+				{Label: "Test", Evaluated: true, Matched: false},
+				{Label: "TestDraft", Evaluated: true, Matched: false},
+				{Label: "TestMergeable", Evaluated: true, Matched: false},
+				{Label: "TestAuthorCanMerge", Evaluated: true, Matched: true},
+				{Label: "TestIsAuthorInTeam", Evaluated: true, Matched: true},
+			},
+		},
+	}
+
+	// Keep only the outcome of each matcher, explanations are
+	// covered in the labeler package
+	for i := range plans {
+		for j := range plans[i].Matchers {
+			plans[i].Matchers[j].Conditions = nil
+		}
+	}
+
+	if !reflect.DeepEqual(expect, plans) {
+		t.Fatalf("\nExpect: %+v\nGot: %+v", expect, plans)
+	}
+}
+
+func TestEvalCommandSummary(t *testing.T) {
+
+	var stdout bytes.Buffer
+	err := evalCommand([]string{
+		"--config", "../test_data/config_v1_issues.yml",
+		"--event", "issues",
+		"--payload", "../test_data/issue_open_payload",
+	}, &stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := strings.Join([]string{
+		"srvaroa/test-repo#1",
+		"  Test: not matched",
+		"Labels to add: ",
+		"Labels to remove: ",
+		"",
+	}, "\n")
+	if expect != stdout.String() {
+		t.Fatalf("\nExpect: %q\nGot: %q", expect, stdout.String())
+	}
+}
+
+func TestRunUnknownCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := runCommand([]string{"nope"}, &stdout, &stderr)
+	if code != 2 {
+		t.Fatalf("Expect exit code 2, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "Available commands: eval") {
+		t.Fatalf("Expect usage in output, got %s", stderr.String())
+	}
+}
+
+func TestValidateCommand(t *testing.T) {
+
+	var stdout bytes.Buffer
+	err := validateCommand([]string{"--config", "../test_data/config_v1.yml"}, &stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout.Reset()
+	err = validateCommand([]string{"--config", "../test_data/teams.yml"}, &stdout)
+	if err == nil {
+		t.Fatalf("Expect an error validating an invalid config")
+	}
+	expect := "../test_data/teams.yml:2:3: expected a mapping\n" +
+		"../test_data/teams.yml:4:3: expected a mapping\n"
+	if expect != stdout.String() {
+		t.Fatalf("\nExpect: %q\nGot: %q", expect, stdout.String())
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"iter"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// perPage is the page size requested to list endpoints, the maximum
+// allowed by the GitHub API
+const perPage = 100
+
+// paginate iterates over all the items returned by a list endpoint,
+// requesting the next page only when the previous one was consumed.
+// list must fetch the page described by opts.
+func paginate[T any](list func(opts github.ListOptions) ([]T, *github.Response, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		opts := github.ListOptions{PerPage: perPage}
+		for {
+			items, resp, err := list(opts)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if resp == nil || resp.NextPage == 0 {
+				return
+			}
+			opts.Page = resp.NextPage
+		}
+	}
+}
+
+// collect gathers all the items of an iterator, stopping at the first
+// error
+func collect[T any](items iter.Seq2[T, error]) ([]T, error) {
+	all := []T{}
+	for item, err := range items {
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, item)
+	}
+	return all, nil
+}
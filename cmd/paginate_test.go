@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestPaginate(t *testing.T) {
+	pages := map[int][]int{
+		0: {1, 2},
+		2: {3, 4},
+		3: {5},
+	}
+	next := map[int]int{0: 2, 2: 3, 3: 0}
+
+	requested := []int{}
+	list := func(opts github.ListOptions) ([]int, *github.Response, error) {
+		if opts.PerPage != perPage {
+			t.Errorf("Expected pages of %d items, got %d", perPage, opts.PerPage)
+		}
+		requested = append(requested, opts.Page)
+		return pages[opts.Page], &github.Response{NextPage: next[opts.Page]}, nil
+	}
+
+	items, err := collect(paginate(list))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual([]int{1, 2, 3, 4, 5}, items) {
+		t.Errorf("Expected items from all pages, got %v", items)
+	}
+
+	// Pages are only requested as they are consumed
+	requested = []int{}
+	for item := range paginate(list) {
+		if item == 2 {
+			break
+		}
+	}
+	if !reflect.DeepEqual([]int{0}, requested) {
+		t.Errorf("Expected only the first page to be requested, got %v", requested)
+	}
+}
+
+func TestPaginateError(t *testing.T) {
+	calls := 0
+	list := func(opts github.ListOptions) ([]int, *github.Response, error) {
+		calls++
+		if opts.Page == 2 {
+			return nil, nil, fmt.Errorf("boom")
+		}
+		return []int{1}, &github.Response{NextPage: 2}, nil
+	}
+
+	items, err := collect(paginate(list))
+	if err == nil || items != nil {
+		t.Errorf("Expected an error and no items, got %v, %v", items, err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected iteration to stop after the error, got %d calls", calls)
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxRetries is the number of times a request is retried after a
+	// transient error or a rate limited response
+	maxRetries = 5
+	// maxRateLimitWait is the longest we are willing to wait for a rate
+	// limit to reset, which is never more than an hour on GitHub
+	maxRateLimitWait = time.Hour
+	// secondaryRateLimitWait is the wait after hitting a secondary rate
+	// limit that doesn't tell when to retry, as recommended by GitHub
+	secondaryRateLimitWait = time.Minute
+	initialBackoff         = time.Second
+	maxBackoff             = 30 * time.Second
+)
+
+// rateQuota is the state of a rate limit as reported by GitHub
+type rateQuota struct {
+	limit     int
+	remaining int
+	reset     time.Time
+}
+
+// rateLimitTransport is an http.RoundTripper for the GitHub API that
+// retries transient errors with exponential backoff, and waits when a
+// rate limit is hit (or about to be) until it resets.  It also tracks
+// the remaining quota of each rate limit so it can be reported at the
+// end of a run.
+type rateLimitTransport struct {
+	base  http.RoundTripper
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+
+	mu     sync.Mutex
+	quotas map[string]rateQuota // by resource (core, search, ...)
+}
+
+func newRateLimitTransport(base http.RoundTripper) *rateLimitTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &rateLimitTransport{
+		base:   base,
+		now:    time.Now,
+		sleep:  sleepContext,
+		quotas: map[string]rateQuota{},
+	}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 {
+			var err error
+			if attemptReq, err = rewindRequest(req); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+		if err == nil {
+			t.recordQuota(resp)
+		}
+
+		wait, reason := t.retryDelay(req, resp, err, attempt)
+		if reason == "" {
+			return resp, err
+		}
+		if attempt >= maxRetries || wait > maxRateLimitWait {
+			log.Printf("Giving up on %s %s after %d attempts: %s",
+				req.Method, req.URL.Path, attempt+1, reason)
+			return resp, err
+		}
+		if resp != nil && resp.StatusCode < 400 {
+			// The response is good, but the next request would be
+			// rejected. Wait here so that it can go through.
+			log.Printf("%s, waiting %s", reason, wait)
+			if err := t.sleep(req.Context(), wait); err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+			return resp, nil
+		}
+
+		log.Printf("%s on %s %s, retrying in %s", reason, req.Method, req.URL.Path, wait)
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryDelay tells how long to wait before retrying the request, and
+// why. An empty reason means that the response must be returned as is.
+func (t *rateLimitTransport) retryDelay(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, string) {
+	backoff := initialBackoff << attempt
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+
+	if err != nil {
+		if isIdempotent(req) && req.Context().Err() == nil {
+			return backoff, fmt.Sprintf("Request failed (%s)", err)
+		}
+		return 0, ""
+	}
+
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+		if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+			if seconds, err := strconv.Atoi(retryAfter); err == nil {
+				return time.Duration(seconds) * time.Second, "Secondary rate limit hit"
+			}
+		}
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			return t.untilReset(resp), "Rate limit exhausted"
+		}
+		if isSecondaryRateLimit(resp) {
+			return secondaryRateLimitWait << attempt, "Secondary rate limit hit"
+		}
+		return 0, ""
+	}
+
+	if resp.StatusCode >= 500 && isIdempotent(req) {
+		return backoff, fmt.Sprintf("Server error (%s)", resp.Status)
+	}
+
+	if resp.StatusCode < 400 && resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		return t.untilReset(resp), "Rate limit exhausted"
+	}
+	return 0, ""
+}
+
+// untilReset returns the time left until the rate limit in the response
+// resets
+func (t *rateLimitTransport) untilReset(resp *http.Response) time.Duration {
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return secondaryRateLimitWait
+	}
+	// Give GitHub's clock some slack
+	wait := time.Unix(reset, 0).Sub(t.now()) + time.Second
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return wait
+}
+
+func (t *rateLimitTransport) recordQuota(resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	limit, _ := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	reset, _ := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	resource := resp.Header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = "core"
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.quotas[resource] = rateQuota{
+		limit:     limit,
+		remaining: remaining,
+		reset:     time.Unix(reset, 0),
+	}
+}
+
+// logQuota reports the remaining quota of each rate limit used in the
+// run
+func (t *rateLimitTransport) logQuota() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	resources := []string{}
+	for resource := range t.quotas {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+	for _, resource := range resources {
+		quota := t.quotas[resource]
+		log.Printf("GitHub API %s quota: %d of %d requests remaining, resets at %s",
+			resource, quota.remaining, quota.limit, quota.reset.UTC().Format(time.RFC3339))
+	}
+}
+
+// isSecondaryRateLimit tells whether a 403 response comes from a
+// secondary rate limit, which is only explained in its body
+func isSecondaryRateLimit(resp *http.Response) bool {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	msg := strings.ToLower(string(body))
+	return strings.Contains(msg, "secondary rate limit") ||
+		strings.Contains(msg, "abuse detection")
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// rewindRequest returns a copy of the request that can be sent again
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return clone, nil
+	}
+	if req.GetBody == nil {
+		return nil, fmt.Errorf("unable to retry %s %s, the request body can't be read again", req.Method, req.URL.Path)
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	clone.Body = body
+	return clone, nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
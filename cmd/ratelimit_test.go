@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+type fakeResponse struct {
+	status  int
+	headers map[string]string
+	body    string
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	reset := strconv.FormatInt(now.Add(10*time.Second).Unix(), 10)
+
+	testCases := []struct {
+		name      string
+		method    string
+		responses []fakeResponse
+		// expected
+		status int
+		body   string
+		calls  int
+		waits  []time.Duration
+	}{
+		{
+			name:      "Successful responses are returned as is",
+			method:    http.MethodGet,
+			responses: []fakeResponse{{status: 200, body: "ok"}},
+			status:    200, body: "ok", calls: 1, waits: []time.Duration{},
+		},
+		{
+			name:   "Server errors are retried with exponential backoff",
+			method: http.MethodGet,
+			responses: []fakeResponse{
+				{status: 502}, {status: 503}, {status: 200, body: "ok"},
+			},
+			status: 200, body: "ok", calls: 3,
+			waits: []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:      "Server errors are not retried on requests that aren't idempotent",
+			method:    http.MethodPost,
+			responses: []fakeResponse{{status: 500, body: "oops"}},
+			status:    500, body: "oops", calls: 1, waits: []time.Duration{},
+		},
+		{
+			name:   "Gives up after the maximum number of retries",
+			method: http.MethodGet,
+			responses: []fakeResponse{
+				{status: 502}, {status: 502}, {status: 502},
+				{status: 502}, {status: 502}, {status: 502, body: "still down"},
+			},
+			status: 502, body: "still down", calls: 6,
+			waits: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second,
+				8 * time.Second, 16 * time.Second},
+		},
+		{
+			name:   "Retry-After is respected",
+			method: http.MethodPost,
+			responses: []fakeResponse{
+				{status: 403, headers: map[string]string{"Retry-After": "3"}},
+				{status: 201, body: "created"},
+			},
+			status: 201, body: "created", calls: 2,
+			waits: []time.Duration{3 * time.Second},
+		},
+		{
+			name:   "Waits for the reset when the rate limit is exhausted",
+			method: http.MethodGet,
+			responses: []fakeResponse{
+				{status: 403, headers: map[string]string{
+					"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset}},
+				{status: 200, body: "ok"},
+			},
+			status: 200, body: "ok", calls: 2,
+			waits: []time.Duration{11 * time.Second},
+		},
+		{
+			name:   "Secondary rate limits without headers wait a minute",
+			method: http.MethodGet,
+			responses: []fakeResponse{
+				{status: 403, body: `{"message": "You have exceeded a secondary rate limit"}`},
+				{status: 200, body: "ok"},
+			},
+			status: 200, body: "ok", calls: 2,
+			waits: []time.Duration{time.Minute},
+		},
+		{
+			name:   "Other forbidden responses are returned as is",
+			method: http.MethodGet,
+			responses: []fakeResponse{
+				{status: 403, body: `{"message": "Resource not accessible by integration"}`},
+			},
+			status: 403, body: `{"message": "Resource not accessible by integration"}`,
+			calls: 1, waits: []time.Duration{},
+		},
+		{
+			name:   "Waits for the reset after the last request in the budget",
+			method: http.MethodGet,
+			responses: []fakeResponse{
+				{status: 200, body: "ok", headers: map[string]string{
+					"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset}},
+			},
+			status: 200, body: "ok", calls: 1,
+			waits: []time.Duration{11 * time.Second},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			bodies := []string{}
+			transport := newRateLimitTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				if req.Body != nil {
+					body, _ := io.ReadAll(req.Body)
+					bodies = append(bodies, string(body))
+				}
+				r := tc.responses[calls]
+				calls++
+				resp := &http.Response{
+					StatusCode: r.status,
+					Status:     fmt.Sprintf("%d %s", r.status, http.StatusText(r.status)),
+					Header:     http.Header{},
+					Body:       io.NopCloser(strings.NewReader(r.body)),
+				}
+				for k, v := range r.headers {
+					resp.Header.Set(k, v)
+				}
+				return resp, nil
+			}))
+			waits := []time.Duration{}
+			transport.now = func() time.Time { return now }
+			transport.sleep = func(ctx context.Context, d time.Duration) error {
+				waits = append(waits, d)
+				return nil
+			}
+
+			req, _ := http.NewRequest(tc.method, "https://api.github.com/repos/o/r", strings.NewReader("payload"))
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != tc.status || string(body) != tc.body {
+				t.Errorf("Expected %d %q, got %d %q", tc.status, tc.body, resp.StatusCode, body)
+			}
+			if calls != tc.calls {
+				t.Errorf("Expected %d calls, got %d", tc.calls, calls)
+			}
+			for _, b := range bodies {
+				if b != "payload" {
+					t.Errorf("Expected the body to be sent on every attempt, got %q", b)
+				}
+			}
+			if !reflect.DeepEqual(tc.waits, waits) {
+				t.Errorf("Expected waits %v, got %v", tc.waits, waits)
+			}
+		})
+	}
+}
+
+func TestRateLimitTransportRecordsQuota(t *testing.T) {
+	transport := newRateLimitTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		header := http.Header{}
+		header.Set("X-RateLimit-Limit", "5000")
+		header.Set("X-RateLimit-Remaining", "4321")
+		header.Set("X-RateLimit-Reset", "1700000000")
+		header.Set("X-RateLimit-Resource", "core")
+		return &http.Response{StatusCode: 200, Header: header, Body: http.NoBody}, nil
+	}))
+
+	req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/repos/o/r", nil)
+	if _, err := transport.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := rateQuota{limit: 5000, remaining: 4321, reset: time.Unix(1700000000, 0)}
+	if quota := transport.quotas["core"]; quota != expected {
+		t.Errorf("Expected quota %+v, got %+v", expected, quota)
+	}
+}
@@ -8,6 +8,7 @@ require (
 	github.com/google/go-github/v50 v50.2.0
 	github.com/waigani/diffparser v0.0.0-20190828052634-7391f219313d
 	golang.org/x/oauth2 v0.36.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
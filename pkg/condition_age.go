@@ -5,15 +5,16 @@ import (
 	"time"
 )
 
-func AgeCondition(l *Labeler) Condition {
+func AgeCondition() Condition {
 	return Condition{
 		GetName: func() string {
 			return "Age of issue/PR"
 		},
+		Keys: []string{"age", "age-range"},
 		CanEvaluate: func(target *Target) bool {
 			return target.ghIssue != nil || target.ghPR != nil
 		},
-		Evaluate: func(target *Target, matcher LabelMatcher) (bool, error) {
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
 			// Backward compatibility: If "age" is provided as a string, treat it as "at-least"
 			var atLeastDuration, atMostDuration time.Duration
 			var err error
@@ -54,6 +55,7 @@ func AgeCondition(l *Labeler) Condition {
 			}
 
 			age := time.Since(createdAt)
+			target.Observe("Created at %s, age is %s", createdAt.Format(time.RFC3339), age.Round(time.Second))
 
 			//	Check if the age of the issue/PR is within the specified range
 			if atLeastDuration != 0 && age < atLeastDuration {
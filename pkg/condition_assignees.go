@@ -0,0 +1,101 @@
+package labeler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type AssigneesConfig struct {
+	// Any matches when any of these users is assigned
+	Any []string
+	// None matches when none of these users is assigned
+	None []string
+	// Team matches when any assignee is an active member of the team
+	Team string
+}
+
+// UnmarshalYAML accepts a list of users as a shorthand for `any`
+func (c *AssigneesConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var users []string
+	if err := unmarshal(&users); err == nil {
+		*c = AssigneesConfig{Any: users}
+		return nil
+	}
+	type plain AssigneesConfig
+	return unmarshal((*plain)(c))
+}
+
+func AssigneesCondition() Condition {
+	return Condition{
+		GetName: func() string {
+			return "Assignees match"
+		},
+		Keys: []string{"assignees"},
+		CanEvaluate: func(target *Target) bool {
+			return true
+		},
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
+			config := matcher.Assignees
+			if config == nil || (len(config.Any) == 0 && len(config.None) == 0 && config.Team == "") {
+				return false, fmt.Errorf("assignees are not set in config")
+			}
+			target.Observe("Assignees are %v", target.Assignees)
+
+			if len(config.Any) > 0 && !isAnyAssigned(target.Assignees, config.Any) {
+				return false, nil
+			}
+			if len(config.None) > 0 && isAnyAssigned(target.Assignees, config.None) {
+				return false, nil
+			}
+			if config.Team != "" {
+				for _, assignee := range target.Assignees {
+					isMember, err := target.IsUserMemberOfTeam(assignee, config.Team)
+					if err != nil {
+						return false, err
+					}
+					if isMember {
+						target.Observe("`%s` is an active member of team `%s`", assignee, config.Team)
+						return true, nil
+					}
+				}
+				target.Observe("No assignee is a member of team `%s`", config.Team)
+				return false, nil
+			}
+			return true, nil
+		},
+	}
+}
+
+func HasAssigneeCondition() Condition {
+	return Condition{
+		GetName: func() string {
+			return "Has assignee"
+		},
+		Keys: []string{"has-assignee"},
+		CanEvaluate: func(target *Target) bool {
+			return true
+		},
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
+			b, err := strconv.ParseBool(matcher.HasAssignee)
+			if err != nil {
+				return false, fmt.Errorf("has-assignee is not set in config")
+			}
+			target.Observe("Assignees are %v", target.Assignees)
+			return (len(target.Assignees) > 0) == b, nil
+		},
+	}
+}
+
+// isAnyAssigned tells whether any of the users is among the assignees,
+// ignoring case as GitHub logins do
+func isAnyAssigned(assignees []string, users []string) bool {
+	for _, user := range users {
+		for _, assignee := range assignees {
+			if strings.EqualFold(user, assignee) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -2,7 +2,6 @@ package labeler
 
 import (
 	"fmt"
-	"log"
 	"strings"
 )
 
@@ -11,15 +10,16 @@ func AuthorCondition() Condition {
 		GetName: func() string {
 			return "Author matches"
 		},
+		Keys: []string{"authors"},
 		CanEvaluate: func(target *Target) bool {
 			return true
 		},
-		Evaluate: func(target *Target, matcher LabelMatcher) (bool, error) {
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
 			if len(matcher.Authors) <= 0 {
 				return false, fmt.Errorf("Users are not set in config")
 			}
 
-			log.Printf("Matching `%s` against: `%v`", matcher.Authors, target.Author)
+			target.Observe("Matching `%s` against: `%v`", matcher.Authors, target.Author)
 			for _, author := range matcher.Authors {
 				if strings.ToLower(author) == strings.ToLower(target.Author) {
 					return true, nil
@@ -2,6 +2,7 @@ package labeler
 
 import (
 	"fmt"
+	"log"
 	"strconv"
 )
 
@@ -10,10 +11,11 @@ func AuthorCanMergeCondition() Condition {
 		GetName: func() string {
 			return "Author can merge"
 		},
+		Keys: []string{"author-can-merge"},
 		CanEvaluate: func(target *Target) bool {
 			return target.ghPR != nil
 		},
-		Evaluate: func(target *Target, matcher LabelMatcher) (bool, error) {
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
 			expected, err := strconv.ParseBool(matcher.AuthorCanMerge)
 			if err != nil {
 				return false, fmt.Errorf("author-can-merge doesn't have a valid value in config")
@@ -21,19 +23,20 @@ func AuthorCanMergeCondition() Condition {
 
 			authorAssoc := target.ghPR.GetAuthorAssociation()
 			canMerge := authorAssoc == "MEMBER" || authorAssoc == "OWNER" || authorAssoc == "COLLABORATOR"
+			target.Observe("Author association is `%s`", authorAssoc)
 
 			if expected && canMerge {
-				fmt.Printf("User: %s can merge, condition matched\n", target.Author)
+				log.Printf("User: %s can merge, condition matched", target.Author)
 				return true, nil
 			}
 
 			if !expected && !canMerge {
-				fmt.Printf("User: %s can not merge, condition matched\n",
+				log.Printf("User: %s can not merge, condition matched",
 					target.Author)
 				return true, nil
 			}
 
-			fmt.Printf("Condition not matched")
+			log.Printf("Condition not matched")
 			return false, nil
 		},
 	}
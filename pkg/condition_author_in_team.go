@@ -4,21 +4,22 @@ import (
 	"fmt"
 )
 
-func AuthorInTeamCondition(l *Labeler) Condition {
+func AuthorInTeamCondition() Condition {
 	return Condition{
 		GetName: func() string {
 			return "Author is member of team"
 		},
+		Keys: []string{"author-in-team"},
 		CanEvaluate: func(target *Target) bool {
 			return true
 		},
-		Evaluate: func(target *Target, matcher LabelMatcher) (bool, error) {
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
 			if len(matcher.AuthorInTeam) <= 0 {
 				return false, fmt.Errorf("author-in-team is not set in config")
 			}
 			// check if author is a member of team
-			return l.GitHubFacade.IsUserMemberOfTeam(
-				target.Owner,
+			target.Observe("Checking if `%s` is an active member of team `%s`", target.Author, matcher.AuthorInTeam)
+			return target.IsUserMemberOfTeam(
 				target.Author,
 				matcher.AuthorInTeam, // this is the team slug
 			)
@@ -2,7 +2,6 @@ package labeler
 
 import (
 	"fmt"
-	"log"
 	"regexp"
 )
 
@@ -11,15 +10,16 @@ func BaseBranchCondition() Condition {
 		GetName: func() string {
 			return "Base branch matches regex"
 		},
+		Keys: []string{"base-branch"},
 		CanEvaluate: func(target *Target) bool {
 			return target.ghPR != nil
 		},
-		Evaluate: func(target *Target, matcher LabelMatcher) (bool, error) {
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
 			if len(matcher.BaseBranch) <= 0 {
 				return false, fmt.Errorf("branch is not set in config")
 			}
 			prBranchName := target.ghPR.Base.GetRef()
-			log.Printf("Matching `%s` against: `%s`", matcher.BaseBranch, prBranchName)
+			target.Observe("Matching `%s` against: `%s`", matcher.BaseBranch, prBranchName)
 			isMatched, _ := regexp.Match(matcher.BaseBranch, []byte(prBranchName))
 			return isMatched, nil
 		},
@@ -2,7 +2,6 @@ package labeler
 
 import (
 	"fmt"
-	"log"
 	"regexp"
 )
 
@@ -11,14 +10,15 @@ func BodyCondition() Condition {
 		GetName: func() string {
 			return "Body matches regex"
 		},
+		Keys: []string{"body"},
 		CanEvaluate: func(target *Target) bool {
 			return true
 		},
-		Evaluate: func(target *Target, matcher LabelMatcher) (bool, error) {
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
 			if len(matcher.Body) <= 0 {
 				return false, fmt.Errorf("body is not set in config")
 			}
-			log.Printf("Matching `%s` against: `%s`", matcher.Body, target.Body)
+			target.Observe("Matching `%s` against: `%s`", matcher.Body, target.Body)
 			isMatched, _ := regexp.Match(matcher.Body, []byte(target.Body))
 			return isMatched, nil
 		},
@@ -0,0 +1,155 @@
+package labeler
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+)
+
+// Overall states of the checks of a PR
+const (
+	CheckStateSuccess = "success"
+	CheckStateFailure = "failure"
+	CheckStatePending = "pending"
+)
+
+type ChecksConfig struct {
+	// State is the expected state of the checks: success, failure or
+	// pending
+	State string
+	// Names restricts the condition to the checks whose name matches
+	// any of these regexes
+	Names []string
+}
+
+// checkResult is the state of a commit status or a check run, reduced to
+// one of the CheckState values
+type checkResult struct {
+	name  string
+	state string
+}
+
+func ChecksCondition() Condition {
+	return Condition{
+		GetName: func() string {
+			return "Pull Request checks"
+		},
+		Keys: []string{"checks"},
+		CanEvaluate: func(target *Target) bool {
+			return target.ghPR != nil
+		},
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
+			config := matcher.Checks
+			if config == nil || (config.State == "" && len(config.Names) == 0) {
+				return false, fmt.Errorf("no checks conditions are set in config")
+			}
+
+			checks, err := target.Checks()
+			if err != nil {
+				return false, err
+			}
+
+			if len(config.Names) > 0 {
+				filtered := []checkResult{}
+				for _, check := range checks {
+					matched, err := matchesAnyRegex(config.Names, check.name)
+					if err != nil {
+						return false, err
+					}
+					if matched {
+						filtered = append(filtered, check)
+					}
+				}
+				checks = filtered
+			}
+
+			for _, check := range checks {
+				target.Observe("Check `%s` is %s", check.name, check.state)
+			}
+			if config.State == "" {
+				return len(checks) > 0, nil
+			}
+
+			state := overallCheckState(checks)
+			target.Observe("Overall state of %d checks is %s", len(checks), state)
+			return state == strings.ToLower(config.State), nil
+		},
+	}
+}
+
+// overallCheckState fails if any check failed, and succeeds when all of
+// them succeeded. Without checks, the state is pending as they may not
+// have been reported yet.
+func overallCheckState(checks []checkResult) string {
+	if len(checks) == 0 {
+		return CheckStatePending
+	}
+	state := CheckStateSuccess
+	for _, check := range checks {
+		if check.state == CheckStateFailure {
+			return CheckStateFailure
+		}
+		if check.state == CheckStatePending {
+			state = CheckStatePending
+		}
+	}
+	return state
+}
+
+func statusResult(status *gh.RepoStatus) checkResult {
+	state := CheckStatePending
+	switch status.GetState() {
+	case "success":
+		state = CheckStateSuccess
+	case "failure", "error":
+		state = CheckStateFailure
+	}
+	return checkResult{name: status.GetContext(), state: state}
+}
+
+func checkRunResult(run *gh.CheckRun) checkResult {
+	state := CheckStatePending
+	if run.GetStatus() == "completed" {
+		switch run.GetConclusion() {
+		case "success", "neutral", "skipped":
+			state = CheckStateSuccess
+		default:
+			state = CheckStateFailure
+		}
+	}
+	return checkResult{name: run.GetName(), state: state}
+}
+
+// isCheckRunOfWorkflowRun tells whether the check run is a job of the
+// given GitHub Actions workflow run
+func isCheckRunOfWorkflowRun(run *gh.CheckRun, runID int64) bool {
+	if runID == 0 {
+		return false
+	}
+	path := fmt.Sprintf("/actions/runs/%d/", runID)
+	return strings.Contains(run.GetDetailsURL(), path) || strings.Contains(run.GetHTMLURL(), path)
+}
+
+func matchesAnyRegex(patterns []string, value string) (bool, error) {
+	for _, pattern := range patterns {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse regex `%s` in configuration: %v", pattern, err)
+		}
+		if regex.MatchString(value) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func validateCheckState(value string) error {
+	switch strings.ToLower(value) {
+	case CheckStateSuccess, CheckStateFailure, CheckStatePending:
+		return nil
+	}
+	return fmt.Errorf("must be `%s`, `%s` or `%s`",
+		CheckStateSuccess, CheckStateFailure, CheckStatePending)
+}
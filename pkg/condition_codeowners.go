@@ -0,0 +1,167 @@
+package labeler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// ErrFileNotFound is returned by GitHubFacade.GetFileContent when the
+// file doesn't exist in the repository
+var ErrFileNotFound = errors.New("file not found")
+
+// codeOwnersPaths are the locations where GitHub looks for the CODEOWNERS
+// file, in order
+var codeOwnersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+type CodeOwnersConfig struct {
+	// Owners matches when any changed file is owned by any of these
+	// users or teams, e.g. `@org/payments`
+	Owners []string
+	// LabelPrefix is prepended to the names of the owners to build the
+	// labels emitted by matchers without a label
+	LabelPrefix string `yaml:"label-prefix"`
+}
+
+// codeOwnersRule is a line of a CODEOWNERS file
+type codeOwnersRule struct {
+	pattern pathPattern
+	owners  []string
+}
+
+// codeOwners are the rules of a CODEOWNERS file, where the last rule that
+// matches a path decides its owners
+type codeOwners []codeOwnersRule
+
+func CodeOwnersCondition() Condition {
+	return Condition{
+		GetName: func() string {
+			return "Code owners match"
+		},
+		Keys: []string{"codeowners"},
+		CanEvaluate: func(target *Target) bool {
+			return target.ghPR != nil
+		},
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
+			if matcher.CodeOwners == nil {
+				return false, fmt.Errorf("codeowners is not set in config")
+			}
+			owners, err := changedFilesOwners(target, matcher.CodeOwners)
+			if err != nil {
+				return false, err
+			}
+			return len(owners) > 0, nil
+		},
+		GenerateLabels: func(target *TargetContext, matcher LabelMatcher) ([]string, error) {
+			config := matcher.CodeOwners
+			if config == nil || config.LabelPrefix == "" {
+				return nil, nil
+			}
+			owners, err := changedFilesOwners(target, config)
+			if err != nil {
+				return nil, err
+			}
+			labels := []string{}
+			for _, owner := range owners {
+				label := config.LabelPrefix + ownerName(owner)
+				if !contains(labels, label) {
+					labels = append(labels, label)
+				}
+			}
+			return labels, nil
+		},
+	}
+}
+
+// changedFilesOwners returns the owners of the files changed in the PR,
+// restricted to those in the config if any
+func changedFilesOwners(target *TargetContext, config *CodeOwnersConfig) ([]string, error) {
+	rules, err := target.CodeOwners()
+	if err != nil {
+		return nil, err
+	}
+	files, err := target.ChangedFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	owners := []string{}
+	for _, file := range files {
+		for _, path := range file.names() {
+			for _, owner := range rules.owners(path) {
+				if len(config.Owners) > 0 && !isListedOwner(config.Owners, owner) {
+					continue
+				}
+				if !contains(owners, owner) {
+					owners = append(owners, owner)
+				}
+			}
+		}
+	}
+	sort.Strings(owners)
+	target.Observe("Changed files are owned by %v", owners)
+	return owners, nil
+}
+
+// parseCodeOwners parses the rules in a CODEOWNERS file, skipping lines
+// with invalid patterns as GitHub does. Unlike .gitignore files, GitHub
+// doesn't support negated patterns, character ranges or escaping with
+// `\`, so lines that use them are skipped too.
+func parseCodeOwners(content string) codeOwners {
+	rules := codeOwners{}
+	for i, line := range strings.Split(content, "\n") {
+		if comment := strings.Index(line, "#"); comment >= 0 {
+			line = line[:comment]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if strings.HasPrefix(fields[0], "!") || strings.ContainsAny(fields[0], "[]\\") {
+			log.Printf("Ignoring unsupported pattern `%s` in line %d of CODEOWNERS", fields[0], i+1)
+			continue
+		}
+		pattern, err := newGlobPattern(fields[0])
+		if err != nil {
+			log.Printf("Ignoring invalid pattern `%s` in line %d of CODEOWNERS", fields[0], i+1)
+			continue
+		}
+		rules = append(rules, codeOwnersRule{pattern: pattern, owners: fields[1:]})
+	}
+	return rules
+}
+
+// owners returns the owners of the path in the last rule that matches it
+func (c codeOwners) owners(path string) []string {
+	if path == "" {
+		return nil
+	}
+	for i := len(c) - 1; i >= 0; i-- {
+		if c[i].pattern.regex.MatchString(path) {
+			return c[i].owners
+		}
+	}
+	return nil
+}
+
+// isListedOwner compares owners ignoring case and the leading `@`
+func isListedOwner(listed []string, owner string) bool {
+	for _, l := range listed {
+		if strings.EqualFold(strings.TrimPrefix(l, "@"), strings.TrimPrefix(owner, "@")) {
+			return true
+		}
+	}
+	return false
+}
+
+// ownerName returns the name of a user (`@alice` is `alice`) or team
+// (`@org/payments` is `payments`) in CODEOWNERS, or the email
+func ownerName(owner string) string {
+	owner = strings.TrimPrefix(owner, "@")
+	if i := strings.LastIndex(owner, "/"); i >= 0 {
+		return owner[i+1:]
+	}
+	return owner
+}
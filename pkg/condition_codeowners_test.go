@@ -0,0 +1,48 @@
+package labeler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCodeOwners(t *testing.T) {
+	rules := parseCodeOwners(`
+# Default owners
+*               @global  # trailing comment
+*.js            @js-owner
+/build/logs/    @doctocat
+docs/*          docs@example.com
+apps/           @octocat
+/apps/github
+\#notes.txt     @hash
+*.[ch]          @c-owner
+/docs/señor/    @i18n
+[invalid        @nobody
+!negated.md     @nobody
+`)
+
+	tests := []struct {
+		path   string
+		owners []string
+	}{
+		{"README.md", []string{"@global"}},
+		{"src/app.js", []string{"@js-owner"}},
+		{"build/logs/app.js", []string{"@doctocat"}},
+		{"src/build/logs/app.js", []string{"@js-owner"}},
+		{"docs/index.md", []string{"docs@example.com"}},
+		{"docs/api/index.md", []string{"@global"}},
+		{"src/apps/main.go", []string{"@octocat"}},
+		{"apps/github/main.go", []string{}},
+		// escapes, character ranges and negations are not supported
+		{"#notes.txt", []string{"@global"}},
+		{"main.c", []string{"@global"}},
+		{"negated.md", []string{"@global"}},
+		{"docs/señor/index.md", []string{"@i18n"}},
+		{"", nil},
+	}
+	for _, test := range tests {
+		if got := rules.owners(test.path); !reflect.DeepEqual(got, test.owners) {
+			t.Errorf("owners of %q: expected %v, got %v", test.path, test.owners, got)
+		}
+	}
+}
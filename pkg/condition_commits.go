@@ -0,0 +1,137 @@
+package labeler
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+)
+
+var coAuthorRegex = regexp.MustCompile(`(?im)^co-authored-by:.*<([^>]+)>\s*$`)
+
+type CommitsConfig struct {
+	// Count bounds the number of commits in the PR
+	Count *CountConfig
+	// Message matches when the message of any commit matches the regex
+	Message string
+	// MultipleAuthors matches when commits were authored by more than
+	// one person, including co-authors
+	MultipleAuthors string `yaml:"multiple-authors"`
+	// Unsigned matches when any commit lacks a verified signature
+	Unsigned string
+}
+
+func CommitsCondition() Condition {
+	return Condition{
+		GetName: func() string {
+			return "Pull Request commits"
+		},
+		Keys: []string{"commits"},
+		CanEvaluate: func(target *Target) bool {
+			return target.ghPR != nil
+		},
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
+			config := matcher.Commits
+			if config == nil {
+				return false, fmt.Errorf("no commits conditions are set in config")
+			}
+
+			commits, err := target.Commits()
+			if err != nil {
+				return false, err
+			}
+
+			if config.Count != nil {
+				target.Observe("PR has %d commits", len(commits))
+				matched, err := config.Count.matches(int64(len(commits)), "commits.count")
+				if err != nil || !matched {
+					return false, err
+				}
+			}
+
+			if config.Message != "" {
+				regex, err := regexp.Compile(config.Message)
+				if err != nil {
+					return false, fmt.Errorf("failed to parse `commits.message` regex: %v", err)
+				}
+				matched := false
+				for _, commit := range commits {
+					if regex.MatchString(commit.GetCommit().GetMessage()) {
+						target.Observe("Message of commit %s matches", shortSHA(commit))
+						matched = true
+						break
+					}
+				}
+				if !matched {
+					target.Observe("No commit message matches `%s`", config.Message)
+					return false, nil
+				}
+			}
+
+			if config.Unsigned != "" {
+				expected, err := strconv.ParseBool(config.Unsigned)
+				if err != nil {
+					return false, fmt.Errorf("failed to parse `commits.unsigned` parameter in configuration: %v", err)
+				}
+				unsigned := []string{}
+				for _, commit := range commits {
+					if !commit.GetCommit().GetVerification().GetVerified() {
+						unsigned = append(unsigned, shortSHA(commit))
+					}
+				}
+				target.Observe("Commits without a verified signature: %v", unsigned)
+				if (len(unsigned) > 0) != expected {
+					return false, nil
+				}
+			}
+
+			if config.MultipleAuthors != "" {
+				expected, err := strconv.ParseBool(config.MultipleAuthors)
+				if err != nil {
+					return false, fmt.Errorf("failed to parse `commits.multiple-authors` parameter in configuration: %v", err)
+				}
+				authors := commitAuthors(commits)
+				target.Observe("Commits are authored by %v", authors)
+				if (len(authors) > 1) != expected {
+					return false, nil
+				}
+			}
+
+			return true, nil
+		},
+	}
+}
+
+// commitAuthors returns the emails of the authors and co-authors of the
+// commits, sorted and without duplicates
+func commitAuthors(commits []*gh.RepositoryCommit) []string {
+	seen := map[string]bool{}
+	add := func(email string) {
+		if email != "" {
+			seen[strings.ToLower(email)] = true
+		}
+	}
+	for _, commit := range commits {
+		add(commit.GetCommit().GetAuthor().GetEmail())
+		for _, m := range coAuthorRegex.FindAllStringSubmatch(commit.GetCommit().GetMessage(), -1) {
+			add(m[1])
+		}
+	}
+	authors := []string{}
+	for email := range seen {
+		authors = append(authors, email)
+	}
+	sort.Strings(authors)
+	return authors
+}
+
+func shortSHA(commit *gh.RepositoryCommit) string {
+	sha := commit.GetSHA()
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,98 @@
+package labeler
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/waigani/diffparser"
+)
+
+type DiffConfig struct {
+	// AddedLines and RemovedLines are regexes matched against the
+	// content of the lines added and removed in the diff
+	AddedLines   string `yaml:"added-lines"`
+	RemovedLines string `yaml:"removed-lines"`
+	// Count bounds the number of matching lines, defaults to at least one
+	Count *CountConfig
+	// Files restricts the condition to the files that match any of
+	// these patterns
+	Files []string
+}
+
+func DiffCondition() Condition {
+	return Condition{
+		GetName: func() string {
+			return "Diff content matches"
+		},
+		Keys: []string{"diff"},
+		CanEvaluate: func(target *Target) bool {
+			return target.ghPR != nil
+		},
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
+			config := matcher.Diff
+			if config == nil || (config.AddedLines == "" && config.RemovedLines == "") {
+				return false, fmt.Errorf("added-lines or removed-lines must be set in diff config")
+			}
+			count := config.Count
+			if count == nil {
+				count = &CountConfig{AtLeast: "1"}
+			}
+
+			diff, err := target.Diff()
+			if err != nil {
+				return false, err
+			}
+
+			for _, lines := range []struct {
+				key     string
+				pattern string
+				mode    diffparser.DiffLineMode
+			}{
+				{"added-lines", config.AddedLines, diffparser.ADDED},
+				{"removed-lines", config.RemovedLines, diffparser.REMOVED},
+			} {
+				if lines.pattern == "" {
+					continue
+				}
+				regex, err := regexp.Compile(lines.pattern)
+				if err != nil {
+					return false, fmt.Errorf("failed to parse `diff.%s` regex: %v", lines.key, err)
+				}
+				matches, err := countDiffLines(diff, config.Files, lines.mode, regex)
+				if err != nil {
+					return false, err
+				}
+				target.Observe("%d lines match `diff.%s`", matches, lines.key)
+				matched, err := count.matches(matches, "diff.count")
+				if err != nil || !matched {
+					return false, err
+				}
+			}
+			return true, nil
+		},
+	}
+}
+
+// countDiffLines counts the lines with the given mode that match the
+// regex, in the files that match the patterns (or all files when there
+// are no patterns)
+func countDiffLines(diff *diffparser.Diff, files []string, mode diffparser.DiffLineMode, regex *regexp.Regexp) (int64, error) {
+	patterns, err := newPathPatterns(files)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	for _, file := range diff.Files {
+		if _, inScope := patterns.matchesAny([]string{file.NewName, file.OrigName}); len(files) > 0 && !inScope {
+			continue
+		}
+		for _, hunk := range file.Hunks {
+			for _, line := range hunk.WholeRange.Lines {
+				if line.Mode == mode && regex.MatchString(line.Content) {
+					count++
+				}
+			}
+		}
+	}
+	return count, nil
+}
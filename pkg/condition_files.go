@@ -2,108 +2,151 @@ package labeler
 
 import (
 	"fmt"
-	"io/ioutil"
 	"log"
-	"net/http"
-	"os"
-	"regexp"
 	"strings"
+)
 
-	gh "github.com/google/go-github/v50/github"
-	"github.com/waigani/diffparser"
+// Change types of the files in a PR
+const (
+	FileStatusAdded    = "added"
+	FileStatusDeleted  = "deleted"
+	FileStatusModified = "modified"
+	FileStatusRenamed  = "renamed"
 )
 
-func FilesCondition(l *Labeler) Condition {
-	prFiles := []string{}
+type FilesConfig struct {
+	// Any, All and None are file patterns that must match any, all or
+	// none of the changed files
+	Any  []string
+	All  []string
+	None []string
+	// Status restricts the condition to the files with any of these
+	// change types
+	Status []string
+}
+
+// UnmarshalYAML accepts a list of patterns as a shorthand for `any`
+func (c *FilesConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var patterns []string
+	if err := unmarshal(&patterns); err == nil {
+		*c = FilesConfig{Any: patterns}
+		return nil
+	}
+	type plain FilesConfig
+	return unmarshal((*plain)(c))
+}
 
+func FilesCondition() Condition {
 	return Condition{
 		GetName: func() string {
-			return "File matches regex"
+			return "Files match"
 		},
+		Keys: []string{"files"},
 		CanEvaluate: func(target *Target) bool {
 			return target.ghPR != nil
 		},
-		Evaluate: func(target *Target, matcher LabelMatcher) (bool, error) {
-
-			if len(matcher.Files) <= 0 {
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
+			config := matcher.Files
+			if config == nil || (len(config.Any) == 0 && len(config.All) == 0 &&
+				len(config.None) == 0 && len(config.Status) == 0) {
 				return false, fmt.Errorf("Files are not set in config")
 			}
 
-			if len(prFiles) == 0 {
-				var err error
-				prFiles, err = l.getPrFileNames(target.ghPR)
+			changed, err := target.ChangedFiles()
+			if err != nil {
+				return false, err
+			}
+			files := []ChangedFile{}
+			for _, file := range changed {
+				if len(config.Status) == 0 || containsFold(config.Status, file.Status) {
+					files = append(files, file)
+				}
+			}
+			if len(config.Status) > 0 {
+				target.Observe("%d files are %s", len(files), strings.Join(config.Status, " or "))
+				if len(files) == 0 {
+					return false, nil
+				}
+			}
+
+			if len(config.Any) > 0 {
+				patterns, err := newPathPatterns(config.Any)
 				if err != nil {
 					return false, err
 				}
+				target.Observe("Matching `%s` against: %s", strings.Join(config.Any, ", "), describeFiles(files))
+				matched := false
+				for _, file := range files {
+					if path, ok := patterns.matchesAny(file.names()); ok {
+						log.Printf("Matched `%s`", path)
+						matched = true
+						break
+					}
+				}
+				if !matched {
+					return false, nil
+				}
 			}
 
-			log.Printf("Matching `%s` against: %s", strings.Join(matcher.Files, ", "), strings.Join(prFiles, ", "))
-			for _, fileMatcher := range matcher.Files {
-				for _, prFile := range prFiles {
-					isMatched, _ := regexp.Match(fileMatcher, []byte(prFile))
-					if isMatched {
-						log.Printf("Matched `%s` against: `%s`", prFile, fileMatcher)
-						return isMatched, nil
+			if len(config.All) > 0 {
+				patterns, err := newPathPatterns(config.All)
+				if err != nil {
+					return false, err
+				}
+				target.Observe("Matching all files against `%s`: %s", strings.Join(config.All, ", "), describeFiles(files))
+				if len(files) == 0 {
+					return false, nil
+				}
+				for _, file := range files {
+					// renamed files must match by both paths
+					if path, ok := patterns.matchesAll(file.names()); !ok {
+						log.Printf("`%s` doesn't match", path)
+						return false, nil
 					}
 				}
 			}
-			return false, nil
-		},
-	}
-}
-
-// getPrFileNames returns all of the file names (old and new) of files changed in the given PR
-func (l *Labeler) getPrFileNames(pr *gh.PullRequest) ([]string, error) {
-	log.Printf("getPrFileNames for pr - %s", pr.GetURL())
-	ghToken := os.Getenv("GITHUB_TOKEN")
-	diffReq, err := http.NewRequest("GET", pr.GetURL(), nil)
 
-	if err != nil {
-		return nil, err
-	}
+			if len(config.None) > 0 {
+				patterns, err := newPathPatterns(config.None)
+				if err != nil {
+					return false, err
+				}
+				target.Observe("Matching no file against `%s`: %s", strings.Join(config.None, ", "), describeFiles(files))
+				for _, file := range files {
+					if path, ok := patterns.matchesAny(file.names()); ok {
+						log.Printf("Matched `%s`", path)
+						return false, nil
+					}
+				}
+			}
 
-	if ghToken != "" {
-		diffReq.Header.Add("Authorization", "Bearer "+ghToken)
-	} else {
-		log.Printf("Env var GITHUB_TOKEN is missing, using annonymous request")
+			return true, nil
+		},
 	}
-	diffReq.Header.Add("Accept", "application/vnd.github.v3.diff")
-	diffRes, err := l.Client.Do(diffReq)
+}
 
-	if err != nil {
-		return nil, err
+func describeFiles(files []ChangedFile) string {
+	names := []string{}
+	for _, file := range files {
+		names = append(names, file.Path)
 	}
+	return strings.Join(names, ", ")
+}
 
-	defer diffRes.Body.Close()
-
-	var diffRaw []byte
-	prFiles := make([]string, 0)
-	if diffRes.StatusCode == http.StatusOK {
-		diffRaw, err = ioutil.ReadAll(diffRes.Body)
-		if err != nil {
-			return nil, err
-		}
-
-		diff, err := diffparser.Parse(string(diffRaw))
-		if err != nil {
-			return nil, err
-		}
-
-		log.Printf("got diff %s, parsed %+v", string(diffRaw), diff)
-		prFilesSet := map[string]struct{}{}
-		// Place in a set to remove duplicates
-		for _, file := range diff.Files {
-			prFilesSet[file.OrigName] = struct{}{}
-			prFilesSet[file.NewName] = struct{}{}
-		}
-		// Convert to list to make it easier to consume
-		for k := range prFilesSet {
-			prFiles = append(prFiles, k)
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
 		}
-		log.Printf("diff files %s", prFiles)
-	} else {
-		log.Printf("failed with status %s", diffRes.Status)
 	}
+	return false
+}
 
-	return prFiles, nil
+func validateFileStatus(value string) error {
+	switch strings.ToLower(value) {
+	case FileStatusAdded, FileStatusDeleted, FileStatusModified, FileStatusRenamed:
+		return nil
+	}
+	return fmt.Errorf("must be `%s`, `%s`, `%s` or `%s`",
+		FileStatusAdded, FileStatusDeleted, FileStatusModified, FileStatusRenamed)
 }
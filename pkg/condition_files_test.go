@@ -0,0 +1,41 @@
+package labeler
+
+import (
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+)
+
+func TestFilesConditionRenames(t *testing.T) {
+	diff := "diff --git a/docs/a.md b/src/a.md\n" +
+		"similarity index 100%\n" +
+		"rename from docs/a.md\n" +
+		"rename to src/a.md\n"
+
+	tests := []struct {
+		name     string
+		files    FilesConfig
+		expected bool
+	}{
+		{"any matches the new path", FilesConfig{Any: []string{"glob:src/"}}, true},
+		{"any matches the previous path", FilesConfig{Any: []string{"glob:docs/"}}, true},
+		{"none excludes the previous path", FilesConfig{None: []string{"glob:docs/"}}, false},
+		{"all requires the new path", FilesConfig{All: []string{"glob:docs/"}}, false},
+		{"all requires the previous path", FilesConfig{All: []string{"glob:src/"}}, false},
+		{"all matches both paths", FilesConfig{All: []string{"glob:docs/", "glob:src/"}}, true},
+	}
+	for _, test := range tests {
+		l := &Labeler{GitHubFacade: &GitHubFacade{
+			GetRawDiff: func(owner, repo string, prNumber int) (string, error) { return diff, nil },
+		}}
+		ctx := l.newTargetContext(&Target{ghPR: &gh.PullRequest{}})
+		files := test.files
+		matched, err := FilesCondition().Evaluate(ctx, LabelMatcher{Files: &files})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matched != test.expected {
+			t.Errorf("%s: expected %t, got %t", test.name, test.expected, matched)
+		}
+	}
+}
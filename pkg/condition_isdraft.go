@@ -10,14 +10,16 @@ func IsDraftCondition() Condition {
 		GetName: func() string {
 			return "Pull Request is draft"
 		},
+		Keys: []string{"draft"},
 		CanEvaluate: func(target *Target) bool {
 			return target.ghPR != nil
 		},
-		Evaluate: func(target *Target, matcher LabelMatcher) (bool, error) {
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
 			b, err := strconv.ParseBool(matcher.Draft)
 			if err != nil {
 				return false, fmt.Errorf("draft is not set in config")
 			}
+			target.Observe("Draft is `%t`", target.ghPR.GetDraft())
 			if b {
 				return target.ghPR.GetDraft(), nil
 			}
@@ -10,17 +10,25 @@ func IsMergeableCondition() Condition {
 		GetName: func() string {
 			return "Pull Request is mergeable"
 		},
+		Keys: []string{"mergeable"},
 		CanEvaluate: func(target *Target) bool {
 			return target.ghPR != nil
 		},
-		Evaluate: func(target *Target, matcher LabelMatcher) (bool, error) {
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
 			b, err := strconv.ParseBool(matcher.Mergeable)
 			if err != nil {
 				return false, fmt.Errorf("mergeable is not set in config")
 			}
 
+			pr, err := target.PR()
+			if err != nil {
+				return false, err
+			}
+
 			//  Check both the mergeable state and the mergeable flag
-			isMergeable := target.ghPR.GetMergeable() && target.ghPR.GetMergeableState() == "clean"
+			isMergeable := pr.GetMergeable() && pr.GetMergeableState() == "clean"
+			target.Observe("Mergeable is `%t`, mergeable state is `%s`",
+				pr.GetMergeable(), pr.GetMergeableState())
 
 			if b {
 				return isMergeable, nil
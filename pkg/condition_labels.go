@@ -0,0 +1,79 @@
+package labeler
+
+import (
+	"fmt"
+)
+
+type LabelsConfig struct {
+	// Each of these lists contains label names, globs or regexes
+	// prefixed with `regex:`, like in managed-labels
+	HasAll  []string `yaml:"has-all"`
+	HasAny  []string `yaml:"has-any"`
+	HasNone []string `yaml:"has-none"`
+}
+
+func LabelsCondition() Condition {
+	return Condition{
+		GetName: func() string {
+			return "Labels match"
+		},
+		Keys: []string{"labels"},
+		CanEvaluate: func(target *Target) bool {
+			return true
+		},
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
+			config := matcher.Labels
+			if config == nil || (len(config.HasAll) == 0 && len(config.HasAny) == 0 && len(config.HasNone) == 0) {
+				return false, fmt.Errorf("labels are not set in config")
+			}
+
+			labels, err := target.Labels()
+			if err != nil {
+				return false, err
+			}
+			target.Observe("Labels are %v", labels)
+
+			for _, pattern := range config.HasAll {
+				found, err := hasLabelMatching(labels, pattern)
+				if err != nil || !found {
+					return false, err
+				}
+			}
+			if len(config.HasAny) > 0 {
+				found := false
+				for _, pattern := range config.HasAny {
+					found, err = hasLabelMatching(labels, pattern)
+					if err != nil {
+						return false, err
+					}
+					if found {
+						break
+					}
+				}
+				if !found {
+					return false, nil
+				}
+			}
+			for _, pattern := range config.HasNone {
+				found, err := hasLabelMatching(labels, pattern)
+				if err != nil || found {
+					return false, err
+				}
+			}
+			return true, nil
+		},
+	}
+}
+
+func hasLabelMatching(labels []string, pattern string) (bool, error) {
+	p, err := newLabelPattern(pattern)
+	if err != nil {
+		return false, fmt.Errorf("invalid label pattern `%s` in configuration: %v", pattern, err)
+	}
+	for _, label := range labels {
+		if p.matches(label) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -7,15 +7,16 @@ import (
 	"github.com/google/go-github/v50/github"
 )
 
-func LastModifiedCondition(l *Labeler) Condition {
+func LastModifiedCondition() Condition {
 	return Condition{
 		GetName: func() string {
 			return "Last modification of issue/PR"
 		},
+		Keys: []string{"last-modified"},
 		CanEvaluate: func(target *Target) bool {
 			return target.ghIssue != nil || target.ghPR != nil
 		},
-		Evaluate: func(target *Target, matcher LabelMatcher) (bool, error) {
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
 			if matcher.LastModified == nil {
 				return false, fmt.Errorf("no last modified conditions are set in config")
 			}
@@ -29,6 +30,8 @@ func LastModifiedCondition(l *Labeler) Condition {
 				return false, fmt.Errorf("no issue or PR found in target")
 			}
 			duration := time.Since(lastModifiedAt.Time)
+			target.Observe("Last modified at %s, %s ago",
+				lastModifiedAt.Time.Format(time.RFC3339), duration.Round(time.Second))
 
 			if matcher.LastModified.AtMost != "" {
 				maxDuration, err := parseExtendedDuration(matcher.LastModified.AtMost)
@@ -0,0 +1,205 @@
+package labeler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+)
+
+// closingReferenceRegex finds the issues that a PR closes, referenced
+// after a closing keyword as `#N`, `owner/repo#N` or the URL of the issue
+//
+// https://docs.github.com/en/issues/tracking-your-work-with-issues/linking-a-pull-request-to-an-issue
+var closingReferenceRegex = regexp.MustCompile(
+	`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+` +
+		`(?:([\w.-]+)/([\w.-]+)#|#|https://github\.com/([\w.-]+)/([\w.-]+)/issues/)(\d+)\b`)
+
+type LinkedIssuesConfig struct {
+	// CopyLabels are the labels of the linked issues that a matcher
+	// without label copies to the PR, as names, globs or regexes like
+	// in managed-labels
+	CopyLabels []string `yaml:"copy-labels"`
+	// Labels, Milestone and State must all match on any linked issue
+	Labels    []string
+	Milestone string
+	State     string
+}
+
+// issueReference identifies an issue in a repository
+type issueReference struct {
+	Owner  string
+	Repo   string
+	Number int
+}
+
+func (r issueReference) String() string {
+	return fmt.Sprintf("%s/%s#%d", r.Owner, r.Repo, r.Number)
+}
+
+func LinkedIssuesCondition() Condition {
+	return Condition{
+		GetName: func() string {
+			return "Linked issues"
+		},
+		Keys: []string{"linked-issues"},
+		CanEvaluate: func(target *Target) bool {
+			return target.ghPR != nil
+		},
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
+			if matcher.LinkedIssues == nil {
+				return false, fmt.Errorf("no linked-issues conditions are set in config")
+			}
+			issues, err := matchingLinkedIssues(target, matcher.LinkedIssues)
+			if err != nil {
+				return false, err
+			}
+			return len(issues) > 0, nil
+		},
+		GenerateLabels: func(target *TargetContext, matcher LabelMatcher) ([]string, error) {
+			config := matcher.LinkedIssues
+			if config == nil || len(config.CopyLabels) == 0 {
+				return nil, nil
+			}
+			patterns := []labelPattern{}
+			for _, pattern := range config.CopyLabels {
+				p, err := newLabelPattern(pattern)
+				if err != nil {
+					return nil, fmt.Errorf("invalid pattern `%s` in linked-issues.copy-labels: %v", pattern, err)
+				}
+				patterns = append(patterns, p)
+			}
+			issues, err := matchingLinkedIssues(target, config)
+			if err != nil {
+				return nil, err
+			}
+			labels := []string{}
+			for _, issue := range issues {
+				for _, label := range issue.Labels {
+					name := label.GetName()
+					if isManagedLabel(patterns, name) && !contains(labels, name) {
+						labels = append(labels, name)
+					}
+				}
+			}
+			sort.Strings(labels)
+			return labels, nil
+		},
+	}
+}
+
+// matchingLinkedIssues returns the issues linked to the target that
+// match the labels, milestone and state in the config
+func matchingLinkedIssues(target *TargetContext, config *LinkedIssuesConfig) ([]*gh.Issue, error) {
+	issues, err := target.LinkedIssues()
+	if err != nil {
+		return nil, err
+	}
+	if len(issues) == 0 {
+		target.Observe("PR doesn't link any issue")
+	}
+
+	matching := []*gh.Issue{}
+	for _, issue := range issues {
+		isMatched, err := linkedIssueMatches(target, issue, config)
+		if err != nil {
+			return nil, err
+		}
+		if isMatched {
+			matching = append(matching, issue)
+		}
+	}
+	return matching, nil
+}
+
+func linkedIssueMatches(target *TargetContext, issue *gh.Issue, config *LinkedIssuesConfig) (bool, error) {
+	labels := []string{}
+	for _, label := range issue.Labels {
+		labels = append(labels, label.GetName())
+	}
+	target.Observe("Linked issue %s is %s with labels %v and milestone `%s`",
+		issue.GetHTMLURL(), issue.GetState(), labels, issue.GetMilestone().GetTitle())
+
+	if config.State != "" && !strings.EqualFold(config.State, issue.GetState()) {
+		return false, nil
+	}
+
+	if config.Milestone != "" {
+		if issue.Milestone == nil {
+			return false, nil
+		}
+		matched, err := matchesAnyRegex([]string{config.Milestone}, issue.GetMilestone().GetTitle())
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	if len(config.Labels) > 0 {
+		hasLabel := false
+		for _, label := range labels {
+			matched, err := matchesAnyRegex(config.Labels, label)
+			if err != nil {
+				return false, err
+			}
+			if matched {
+				hasLabel = true
+				break
+			}
+		}
+		if !hasLabel {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// parseClosingReferences returns the issues referenced after closing
+// keywords in the body, in order and without duplicates. References
+// without a repository belong to the given one.
+func parseClosingReferences(body, owner, repo string) []issueReference {
+	refs := []issueReference{}
+	seen := map[issueReference]bool{}
+	for _, m := range closingReferenceRegex.FindAllStringSubmatch(body, -1) {
+		ref := issueReference{Owner: owner, Repo: repo}
+		if m[1] != "" {
+			ref.Owner, ref.Repo = m[1], m[2]
+		} else if m[3] != "" {
+			ref.Owner, ref.Repo = m[3], m[4]
+		}
+		ref.Number, _ = strconv.Atoi(m[5])
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+func validateIssueState(value string) error {
+	switch strings.ToLower(value) {
+	case "open", "closed":
+		return nil
+	}
+	return fmt.Errorf("must be `open` or `closed`")
+}
+
+// isInaccessible tells whether the error is GitHub reporting that a
+// resource doesn't exist or can't be read with the current token. Rate
+// limits are reported with other error types.
+func isInaccessible(err error) bool {
+	var errResp *gh.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return false
+	}
+	switch errResp.Response.StatusCode {
+	case http.StatusForbidden, http.StatusNotFound, http.StatusGone:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package labeler
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// NoMilestone matches targets without a milestone in the milestone
+// condition
+const NoMilestone = "none"
+
+func MilestoneCondition() Condition {
+	return Condition{
+		GetName: func() string {
+			return "Milestone matches"
+		},
+		Keys: []string{"milestone"},
+		CanEvaluate: func(target *Target) bool {
+			return true
+		},
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
+			if len(matcher.Milestone) <= 0 {
+				return false, fmt.Errorf("milestone is not set in config")
+			}
+			target.Observe("Milestone is `%s`", target.Milestone)
+			if strings.EqualFold(matcher.Milestone, NoMilestone) {
+				return target.Milestone == "", nil
+			}
+			if target.Milestone == "" {
+				return false, nil
+			}
+			regex, err := regexp.Compile(matcher.Milestone)
+			if err != nil {
+				return false, fmt.Errorf("failed to parse milestone regex: %v", err)
+			}
+			return regex.MatchString(target.Milestone), nil
+		},
+	}
+}
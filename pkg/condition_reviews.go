@@ -0,0 +1,158 @@
+package labeler
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+)
+
+type CountConfig struct {
+	AtLeast string `yaml:"at-least"`
+	AtMost  string `yaml:"at-most"`
+}
+
+type ReviewsConfig struct {
+	Approvals                 *CountConfig
+	ApprovedByTeam            string `yaml:"approved-by-team"`
+	ChangesRequested          string `yaml:"changes-requested"`
+	RequestedReviewersPending string `yaml:"requested-reviewers-pending"`
+}
+
+func ReviewsCondition() Condition {
+	return Condition{
+		GetName: func() string {
+			return "Pull Request reviews"
+		},
+		Keys: []string{"reviews"},
+		CanEvaluate: func(target *Target) bool {
+			return target.ghPR != nil
+		},
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
+			config := matcher.Reviews
+			if config == nil {
+				return false, fmt.Errorf("no reviews conditions are set in config")
+			}
+
+			reviews, err := target.Reviews()
+			if err != nil {
+				return false, err
+			}
+			states := latestReviewStates(reviews)
+
+			if config.Approvals != nil {
+				approvals := len(usersInState(states, "APPROVED"))
+				target.Observe("PR has %d approvals", approvals)
+				matched, err := config.Approvals.matches(int64(approvals), "reviews.approvals")
+				if err != nil || !matched {
+					return false, err
+				}
+			}
+
+			if config.ChangesRequested != "" {
+				expected, err := strconv.ParseBool(config.ChangesRequested)
+				if err != nil {
+					return false, fmt.Errorf("failed to parse `reviews.changes-requested` parameter in configuration: %v", err)
+				}
+				requesters := usersInState(states, "CHANGES_REQUESTED")
+				target.Observe("Changes requested by %v", requesters)
+				if (len(requesters) > 0) != expected {
+					return false, nil
+				}
+			}
+
+			if config.RequestedReviewersPending != "" {
+				expected, err := strconv.ParseBool(config.RequestedReviewersPending)
+				if err != nil {
+					return false, fmt.Errorf("failed to parse `reviews.requested-reviewers-pending` parameter in configuration: %v", err)
+				}
+				pending, err := target.RequestedReviewers()
+				if err != nil {
+					return false, err
+				}
+				users, teams := []string{}, []string{}
+				for _, user := range pending.Users {
+					users = append(users, user.GetLogin())
+				}
+				for _, team := range pending.Teams {
+					teams = append(teams, team.GetSlug())
+				}
+				target.Observe("Pending reviews from users %v and teams %v", users, teams)
+				if (len(users)+len(teams) > 0) != expected {
+					return false, nil
+				}
+			}
+
+			if config.ApprovedByTeam != "" {
+				approved := false
+				for _, user := range usersInState(states, "APPROVED") {
+					isMember, err := target.IsUserMemberOfTeam(user, config.ApprovedByTeam)
+					if err != nil {
+						return false, err
+					}
+					if isMember {
+						target.Observe("Approved by %s, member of team %s", user, config.ApprovedByTeam)
+						approved = true
+						break
+					}
+				}
+				if !approved {
+					target.Observe("No approvals from members of team %s", config.ApprovedByTeam)
+					return false, nil
+				}
+			}
+
+			return true, nil
+		},
+	}
+}
+
+// matches tells whether the count is within the bounds in the config
+func (c *CountConfig) matches(count int64, name string) (bool, error) {
+	if c.AtLeast != "" {
+		atLeast, err := strconv.ParseInt(c.AtLeast, 0, 64)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse `%s.at-least` parameter in configuration: %v", name, err)
+		}
+		if count < atLeast {
+			return false, nil
+		}
+	}
+	if c.AtMost != "" {
+		atMost, err := strconv.ParseInt(c.AtMost, 0, 64)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse `%s.at-most` parameter in configuration: %v", name, err)
+		}
+		if count > atMost {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// latestReviewStates returns the state of the last review of each user
+// that approved, requested changes or was dismissed. Comments don't
+// change the state of a previous review.
+func latestReviewStates(reviews []*gh.PullRequestReview) map[string]string {
+	states := map[string]string{}
+	for _, review := range reviews {
+		switch state := strings.ToUpper(review.GetState()); state {
+		case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+			states[review.GetUser().GetLogin()] = state
+		}
+	}
+	return states
+}
+
+func usersInState(states map[string]string, state string) []string {
+	users := []string{}
+	for user, s := range states {
+		if s == state {
+			users = append(users, user)
+		}
+	}
+	sort.Strings(users)
+	return users
+}
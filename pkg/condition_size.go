@@ -4,22 +4,20 @@ import (
 	"fmt"
 	"log"
 	"math"
-	"regexp"
 	"strconv"
 	"strings"
-
-	gh "github.com/google/go-github/v50/github"
 )
 
-func SizeCondition(l *Labeler) Condition {
+func SizeCondition() Condition {
 	return Condition{
 		GetName: func() string {
 			return "Pull Request contains a number of changes"
 		},
+		Keys: []string{"size", "size-above", "size-below"},
 		CanEvaluate: func(target *Target) bool {
 			return target.ghPR != nil
 		},
-		Evaluate: func(target *Target, matcher LabelMatcher) (bool, error) {
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
 
 			if isNewConfig(matcher) && isOldConfig(matcher) {
 				log.Printf("WARNING: you are using both the old " +
@@ -53,8 +51,11 @@ func SizeCondition(l *Labeler) Condition {
 				log.Printf("Lower boundary set to 0 (config has invalid or empty value)")
 			}
 
-			totalChanges, err := l.getModifiedLinesCount(target.ghPR, realMatcher.ExcludeFiles)
-			log.Printf("Matching %d changes in PR against bounds: (%d, %d)", totalChanges, lowerBound, upperBound)
+			totalChanges, err := getModifiedLinesCount(target, realMatcher.ExcludeFiles)
+			if err != nil {
+				return false, err
+			}
+			target.Observe("Matching %d changes in PR against bounds: (%d, %d)", totalChanges, lowerBound, upperBound)
 			isWithinBounds := totalChanges > lowerBound && totalChanges < upperBound
 			return isWithinBounds, nil
 		},
@@ -69,19 +70,30 @@ func isOldConfig(matcher LabelMatcher) bool {
 	return matcher.SizeAbove != "" || matcher.SizeBelow != ""
 }
 
-func (l *Labeler) getModifiedLinesCount(pr *gh.PullRequest, exclusions []string) (int64, error) {
+func getModifiedLinesCount(target *TargetContext, exclusions []string) (int64, error) {
 
 	if len(exclusions) == 0 {
 		// no exclusions so we can just rely on GH's summary which is
 		// more lightweight
+		pr, err := target.PR()
+		if err != nil {
+			return 0, err
+		}
 		return int64(math.Abs(float64(pr.GetAdditions() + pr.GetDeletions()))), nil
 	}
 
+	excluded := pathPatterns{}
+	for _, exclusion := range exclusions {
+		p, err := newPathPattern(exclusion)
+		if err != nil {
+			log.Printf("Error compiling file exclusion %s: %s", exclusion, err)
+			continue
+		}
+		excluded = append(excluded, p)
+	}
+
 	// Get the diff for the pull request
-	urlParts := strings.Split(pr.GetBase().GetRepo().GetHTMLURL(), "/")
-	owner := urlParts[len(urlParts)-2]
-	repo := urlParts[len(urlParts)-1]
-	diff, err := l.GitHubFacade.GetRawDiff(owner, repo, pr.GetNumber())
+	diff, err := target.RawDiff()
 	if err != nil {
 		return 0, err
 	}
@@ -102,7 +114,7 @@ func (l *Labeler) getModifiedLinesCount(pr *gh.PullRequest, exclusions []string)
 			path = strings.TrimPrefix(path, "b/")
 			path = strings.TrimSpace(path)
 			// Check if the file path matches any of the excluded files
-			countFile = !isFileExcluded(path, exclusions)
+			countFile = !excluded.matches(path)
 			if countFile {
 				log.Printf("Counting changes in file %s", path)
 			} else {
@@ -120,15 +132,3 @@ func (l *Labeler) getModifiedLinesCount(pr *gh.PullRequest, exclusions []string)
 
 	return count, nil
 }
-
-func isFileExcluded(path string, exclusions []string) bool {
-	for _, exclusion := range exclusions {
-		exclusionRegex, err := regexp.Compile(exclusion)
-		if err != nil {
-			log.Printf("Error compiling file exclusion regex %s: %s", exclusion, err)
-		} else if exclusionRegex.MatchString(path) {
-			return true
-		}
-	}
-	return false
-}
@@ -2,7 +2,6 @@ package labeler
 
 import (
 	"fmt"
-	"log"
 	"regexp"
 )
 
@@ -11,14 +10,15 @@ func TitleCondition() Condition {
 		GetName: func() string {
 			return "Title matches regex"
 		},
+		Keys: []string{"title"},
 		CanEvaluate: func(target *Target) bool {
 			return true
 		},
-		Evaluate: func(target *Target, matcher LabelMatcher) (bool, error) {
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
 			if len(matcher.Title) <= 0 {
 				return false, fmt.Errorf("title is not set in config")
 			}
-			log.Printf("Matching `%s` against: `%s`", matcher.Title, target.Title)
+			target.Observe("Matching `%s` against: `%s`", matcher.Title, target.Title)
 			isMatched, _ := regexp.Match(matcher.Title, []byte(target.Title))
 			return isMatched, nil
 		},
@@ -2,7 +2,6 @@ package labeler
 
 import (
 	"fmt"
-	"log"
 )
 
 func TypeCondition() Condition {
@@ -10,10 +9,11 @@ func TypeCondition() Condition {
 		GetName: func() string {
 			return "Target type matches defined type"
 		},
+		Keys: []string{"type"},
 		CanEvaluate: func(target *Target) bool {
 			return true
 		},
-		Evaluate: func(target *Target, matcher LabelMatcher) (bool, error) {
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
 			if len(matcher.Type) <= 0 {
 				return false, fmt.Errorf("type is not set in config")
 			} else if matcher.Type != "pull_request" && matcher.Type != "issue" {
@@ -29,7 +29,7 @@ func TypeCondition() Condition {
 				return false, fmt.Errorf("target is neither pull_request nor issue")
 			}
 
-			log.Printf("Matching `%s` against: `%s`", matcher.Type, targetType)
+			target.Observe("Matching `%s` against: `%s`", matcher.Type, targetType)
 			return matcher.Type == targetType || matcher.Type == "all", nil
 		},
 	}
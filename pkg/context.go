@@ -0,0 +1,367 @@
+package labeler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	gh "github.com/google/go-github/v50/github"
+	"github.com/waigani/diffparser"
+)
+
+// TargetContext is passed to conditions when evaluating a target. Along
+// with the target, it gives access to data that requires calls to
+// GitHub, which is fetched lazily and only once per target no matter how
+// many conditions or matchers use it. A new context is created for every
+// target, so nothing leaks from one target to the next.
+type TargetContext struct {
+	*Target
+	labeler *Labeler
+
+	pr      lazyValue[*gh.PullRequest]
+	rawDiff lazyValue[string]
+	diff    lazyValue[*diffparser.Diff]
+	changed lazyValue[[]ChangedFile]
+	reviews lazyValue[[]*gh.PullRequestReview]
+	pending lazyValue[*gh.Reviewers]
+	checks  lazyValue[[]checkResult]
+	linked  lazyValue[[]*gh.Issue]
+	commits lazyValue[[]*gh.RepositoryCommit]
+	owners  lazyValue[codeOwners]
+
+	// currentLabels are the labels of the target before this execution,
+	// and updates the decisions made by the matchers evaluated so far
+	currentLabels []string
+	updates       *LabelUpdates
+	appendOnly    bool
+
+	mu    sync.Mutex
+	teams map[string]*lazyValue[bool]
+}
+
+// lazyValue memoizes the result of fetching a value
+type lazyValue[T any] struct {
+	once  sync.Once
+	value T
+	err   error
+}
+
+func (v *lazyValue[T]) get(fetch func() (T, error)) (T, error) {
+	v.once.Do(func() {
+		v.value, v.err = fetch()
+	})
+	return v.value, v.err
+}
+
+func (l *Labeler) newTargetContext(target *Target) *TargetContext {
+	return &TargetContext{
+		Target:  target,
+		labeler: l,
+		teams:   map[string]*lazyValue[bool]{},
+	}
+}
+
+// PR returns the full details of the target PR. PRs obtained from list
+// endpoints lack some fields (e.g. additions, mergeable state), in which
+// case the PR is fetched from GitHub (see #173).
+func (c *TargetContext) PR() (*gh.PullRequest, error) {
+	return c.pr.get(func() (*gh.PullRequest, error) {
+		if c.ghPR == nil {
+			return nil, fmt.Errorf("target is not a pull request")
+		}
+		if c.ghPR.Additions != nil || c.labeler.GitHubFacade.GetPR == nil {
+			return c.ghPR, nil
+		}
+		log.Printf("Fetching full details of PR #%d", c.IssueNo)
+		return c.labeler.GitHubFacade.GetPR(c.Owner, c.RepoName, c.IssueNo)
+	})
+}
+
+// RawDiff returns the diff of the target PR
+func (c *TargetContext) RawDiff() (string, error) {
+	return c.rawDiff.get(func() (string, error) {
+		if c.ghPR == nil {
+			return "", fmt.Errorf("target is not a pull request")
+		}
+		log.Printf("Fetching diff of PR #%d", c.IssueNo)
+		if c.labeler.GitHubFacade.GetRawDiff == nil && c.labeler.Client != nil {
+			return c.fetchRawDiff()
+		}
+		if c.labeler.GitHubFacade.GetRawDiff == nil {
+			return "", fmt.Errorf("fetching diffs is not supported")
+		}
+		return c.labeler.GitHubFacade.GetRawDiff(c.Owner, c.RepoName, c.IssueNo)
+	})
+}
+
+// fetchRawDiff downloads the diff of the target PR with the labeler's
+// HttpClient
+func (c *TargetContext) fetchRawDiff() (string, error) {
+	req, err := http.NewRequest(http.MethodGet, c.ghPR.GetURL(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Add("Accept", "application/vnd.github.v3.diff")
+	res, err := c.labeler.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to fetch diff of PR #%d: %s", c.IssueNo, res.Status)
+	}
+	raw, err := io.ReadAll(res.Body)
+	return string(raw), err
+}
+
+// Diff returns the parsed diff of the target PR
+func (c *TargetContext) Diff() (*diffparser.Diff, error) {
+	return c.diff.get(func() (*diffparser.Diff, error) {
+		raw, err := c.RawDiff()
+		if err != nil {
+			return nil, err
+		}
+		return diffparser.Parse(raw)
+	})
+}
+
+// ChangedFile is a file changed in a PR
+type ChangedFile struct {
+	Path string
+	// PreviousPath is the path of a renamed file before the change
+	PreviousPath string
+	// Status is added, deleted, modified or renamed
+	Status string
+}
+
+// names returns the current and previous paths of the file
+func (f ChangedFile) names() []string {
+	if f.PreviousPath == "" {
+		return []string{f.Path}
+	}
+	return []string{f.Path, f.PreviousPath}
+}
+
+// ChangedFiles returns the files changed in the target PR along with
+// their change type
+func (c *TargetContext) ChangedFiles() ([]ChangedFile, error) {
+	return c.changed.get(func() ([]ChangedFile, error) {
+		diff, err := c.Diff()
+		if err != nil {
+			return nil, err
+		}
+		files := []ChangedFile{}
+		for _, file := range diff.Files {
+			files = append(files, newChangedFile(file))
+		}
+		return files, nil
+	})
+}
+
+// newChangedFile takes the names from the header of the diff when
+// there are no changes in the content of the file (e.g. renames)
+func newChangedFile(file *diffparser.DiffFile) ChangedFile {
+	origName, newName := file.OrigName, file.NewName
+	if origName == "" && newName == "" {
+		header := strings.SplitN(file.DiffHeader, "\n", 2)[0]
+		header = strings.TrimPrefix(header, "diff --git a/")
+		if names := strings.SplitN(header, " b/", 2); len(names) == 2 {
+			origName, newName = names[0], names[1]
+		}
+	}
+	switch {
+	case file.Mode == diffparser.NEW:
+		return ChangedFile{Path: newName, Status: FileStatusAdded}
+	case file.Mode == diffparser.DELETED:
+		return ChangedFile{Path: origName, Status: FileStatusDeleted}
+	case origName != newName:
+		return ChangedFile{Path: newName, PreviousPath: origName, Status: FileStatusRenamed}
+	}
+	return ChangedFile{Path: newName, Status: FileStatusModified}
+}
+
+// Reviews returns the reviews submitted on the target PR
+func (c *TargetContext) Reviews() ([]*gh.PullRequestReview, error) {
+	return c.reviews.get(func() ([]*gh.PullRequestReview, error) {
+		if c.ghPR == nil {
+			return nil, fmt.Errorf("target is not a pull request")
+		}
+		if c.labeler.GitHubFacade.ListReviews == nil {
+			return nil, fmt.Errorf("listing reviews is not supported")
+		}
+		reviews := []*gh.PullRequestReview{}
+		for review, err := range c.labeler.GitHubFacade.ListReviews(c.Owner, c.RepoName, c.IssueNo) {
+			if err != nil {
+				return nil, err
+			}
+			reviews = append(reviews, review)
+		}
+		return reviews, nil
+	})
+}
+
+// RequestedReviewers returns the users and teams whose review was
+// requested on the target PR and haven't reviewed it yet
+func (c *TargetContext) RequestedReviewers() (*gh.Reviewers, error) {
+	return c.pending.get(func() (*gh.Reviewers, error) {
+		if c.ghPR == nil {
+			return nil, fmt.Errorf("target is not a pull request")
+		}
+		if c.labeler.GitHubFacade.ListRequestedReviewers == nil {
+			return nil, fmt.Errorf("listing requested reviewers is not supported")
+		}
+		return c.labeler.GitHubFacade.ListRequestedReviewers(c.Owner, c.RepoName, c.IssueNo)
+	})
+}
+
+// Checks returns the commit statuses and check runs reported on the head
+// commit of the target PR
+func (c *TargetContext) Checks() ([]checkResult, error) {
+	return c.checks.get(func() ([]checkResult, error) {
+		if c.ghPR == nil {
+			return nil, fmt.Errorf("target is not a pull request")
+		}
+		facade := c.labeler.GitHubFacade
+		if facade.ListStatuses == nil || facade.ListCheckRuns == nil {
+			return nil, fmt.Errorf("listing checks is not supported")
+		}
+		sha := c.ghPR.GetHead().GetSHA()
+		log.Printf("Fetching checks of commit %s", sha)
+		checks := []checkResult{}
+		for status, err := range facade.ListStatuses(c.Owner, c.RepoName, sha) {
+			if err != nil {
+				return nil, err
+			}
+			checks = append(checks, statusResult(status))
+		}
+		for run, err := range facade.ListCheckRuns(c.Owner, c.RepoName, sha) {
+			if err != nil {
+				return nil, err
+			}
+			if isCheckRunOfWorkflowRun(run, c.labeler.WorkflowRunID) {
+				log.Printf("Ignoring check run %s of the current workflow run", run.GetName())
+				continue
+			}
+			checks = append(checks, checkRunResult(run))
+		}
+		return checks, nil
+	})
+}
+
+// LinkedIssues returns the issues that the target PR closes, as
+// referenced in its body
+func (c *TargetContext) LinkedIssues() ([]*gh.Issue, error) {
+	return c.linked.get(func() ([]*gh.Issue, error) {
+		if c.labeler.GitHubFacade.GetIssue == nil {
+			return nil, fmt.Errorf("fetching issues is not supported")
+		}
+		issues := []*gh.Issue{}
+		for _, ref := range parseClosingReferences(c.Body, c.Owner, c.RepoName) {
+			log.Printf("Fetching linked issue %s", ref)
+			issue, err := c.labeler.GitHubFacade.GetIssue(ref.Owner, ref.Repo, ref.Number)
+			if isInaccessible(err) {
+				// e.g. a deleted issue or one in a private repository
+				log.Printf("Skipping linked issue %s: %s", ref, err)
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("unable to fetch linked issue %s: %w", ref, err)
+			}
+			if issue.IsPullRequest() {
+				continue
+			}
+			issues = append(issues, issue)
+		}
+		return issues, nil
+	})
+}
+
+// Commits returns the commits of the target PR
+func (c *TargetContext) Commits() ([]*gh.RepositoryCommit, error) {
+	return c.commits.get(func() ([]*gh.RepositoryCommit, error) {
+		if c.ghPR == nil {
+			return nil, fmt.Errorf("target is not a pull request")
+		}
+		if c.labeler.GitHubFacade.ListCommits == nil {
+			return nil, fmt.Errorf("listing commits is not supported")
+		}
+		log.Printf("Fetching commits of PR %d", c.IssueNo)
+		commits := []*gh.RepositoryCommit{}
+		for commit, err := range c.labeler.GitHubFacade.ListCommits(c.Owner, c.RepoName, c.IssueNo) {
+			if err != nil {
+				return nil, err
+			}
+			commits = append(commits, commit)
+		}
+		return commits, nil
+	})
+}
+
+// CodeOwners returns the rules in the CODEOWNERS file of the base branch
+// of the target PR
+func (c *TargetContext) CodeOwners() (codeOwners, error) {
+	return c.owners.get(func() (codeOwners, error) {
+		if c.ghPR == nil {
+			return nil, fmt.Errorf("target is not a pull request")
+		}
+		if c.labeler.GitHubFacade.GetFileContent == nil {
+			return nil, fmt.Errorf("fetching files is not supported")
+		}
+		ref := c.ghPR.GetBase().GetRef()
+		for _, path := range codeOwnersPaths {
+			content, err := c.labeler.GitHubFacade.GetFileContent(c.Owner, c.RepoName, path, ref)
+			if errors.Is(err, ErrFileNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("unable to fetch %s: %w", path, err)
+			}
+			log.Printf("Loaded code owners from %s@%s", path, ref)
+			return parseCodeOwners(content), nil
+		}
+		return nil, fmt.Errorf("no CODEOWNERS file found in %s", ref)
+	})
+}
+
+// Labels returns the labels of the target as decided so far: its current
+// labels, with the labels set or unset by the matchers evaluated before
+// the current one, in the order of the config.
+func (c *TargetContext) Labels() ([]string, error) {
+	if c.updates == nil {
+		return nil, fmt.Errorf("current labels are not available")
+	}
+	labels := []string{}
+	for _, label := range c.currentLabels {
+		if isSet, ok := c.updates.set[label]; c.appendOnly || !ok || isSet {
+			labels = append(labels, label)
+		}
+	}
+	for label, isSet := range c.updates.set {
+		if isSet && !contains(labels, label) {
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+	return labels, nil
+}
+
+// IsUserMemberOfTeam tells whether the user is an active member of the
+// team in the organization that owns the target repository
+func (c *TargetContext) IsUserMemberOfTeam(user, team string) (bool, error) {
+	c.mu.Lock()
+	membership, ok := c.teams[user+"@"+team]
+	if !ok {
+		membership = &lazyValue[bool]{}
+		c.teams[user+"@"+team] = membership
+	}
+	c.mu.Unlock()
+
+	return membership.get(func() (bool, error) {
+		return c.labeler.GitHubFacade.IsUserMemberOfTeam(c.Owner, user, team)
+	})
+}
@@ -0,0 +1,223 @@
+package labeler
+
+import (
+	"io"
+	"iter"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+)
+
+func TestProcessAllPRsFetchesDataOncePerPR(t *testing.T) {
+	diffs := map[int]string{
+		1: "diff --git a/README.md b/README.md\n" +
+			"--- a/README.md\n" +
+			"+++ b/README.md\n" +
+			"@@ -1 +1 @@\n" +
+			"-old\n" +
+			"+new\n",
+		2: "diff --git a/main.go b/main.go\n" +
+			"--- a/main.go\n" +
+			"+++ b/main.go\n" +
+			"@@ -1 +1,2 @@\n" +
+			"-old\n" +
+			"+new\n" +
+			"+newer\n",
+	}
+	repo := &gh.PullRequestBranch{Repo: &gh.Repository{Name: gh.String("repo"), Owner: &gh.User{Login: gh.String("y")}}}
+	fakePRs := []*gh.PullRequest{
+		{Number: gh.Int(1), State: gh.String("open"), User: &gh.User{Login: gh.String("user1")}, Base: repo},
+		{Number: gh.Int(2), State: gh.String("open"), User: &gh.User{Login: gh.String("user2")}, Base: repo},
+	}
+
+	diffCalls := map[int]int{}
+	prCalls := map[int]int{}
+	labels := map[int][]string{}
+	l := &Labeler{
+		FetchRepoConfig: func() (*LabelerConfigV1, error) {
+			return &LabelerConfigV1{Version: 1, Labels: []LabelMatcher{
+				{Label: "Docs", Files: &FilesConfig{Any: []string{"\\.md$"}}},
+				{Label: "Code", Files: &FilesConfig{Any: []string{"\\.go$"}}},
+				{Label: "Small", Size: &SizeConfig{Below: "3", ExcludeFiles: []string{"\\.txt$"}}},
+				{Label: "Tiny", SizeBelow: "3"},
+				{Label: "Mergeable", Mergeable: "True"},
+			}}, nil
+		},
+		GetCurrentLabels: func(target *Target) ([]string, error) { return nil, nil },
+		AddLabels: func(target *Target, l []string) error {
+			labels[target.IssueNo] = l
+			return nil
+		},
+		GitHubFacade: &GitHubFacade{
+			ListPRs: func(owner, repo string) iter.Seq2[*gh.PullRequest, error] { return listOf(fakePRs) },
+			GetRawDiff: func(owner, repo string, prNumber int) (string, error) {
+				diffCalls[prNumber]++
+				return diffs[prNumber], nil
+			},
+			GetPR: func(owner, repo string, prNumber int) (*gh.PullRequest, error) {
+				prCalls[prNumber]++
+				return &gh.PullRequest{
+					Number:         gh.Int(prNumber),
+					Additions:      gh.Int(prNumber),
+					Deletions:      gh.Int(1),
+					Mergeable:      gh.Bool(true),
+					MergeableState: gh.String("clean"),
+				}, nil
+			},
+		},
+	}
+
+	l.ProcessAllPRs("y", "repo")
+
+	expectedLabels := map[int][]string{
+		1: {"Docs", "Mergeable", "Small", "Tiny"},
+		2: {"Code", "Mergeable"},
+	}
+	if !reflect.DeepEqual(expectedLabels, labels) {
+		t.Errorf("Expected labels %+v, got %+v", expectedLabels, labels)
+	}
+	expectedCalls := map[int]int{1: 1, 2: 1}
+	if !reflect.DeepEqual(expectedCalls, diffCalls) {
+		t.Errorf("Expected the diff to be fetched once per PR, got %+v", diffCalls)
+	}
+	if !reflect.DeepEqual(expectedCalls, prCalls) {
+		t.Errorf("Expected the PR to be fetched once per PR, got %+v", prCalls)
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	diff := "diff --git a/old.go b/new.go\n" +
+		"similarity index 100%\n" +
+		"rename from old.go\n" +
+		"rename to new.go\n" +
+		"diff --git a/docs/a.md b/docs/b.md\n" +
+		"similarity index 90%\n" +
+		"rename from docs/a.md\n" +
+		"rename to docs/b.md\n" +
+		"index 6c61a60..85aa975 100644\n" +
+		"--- a/docs/a.md\n" +
+		"+++ b/docs/b.md\n" +
+		"@@ -1 +1 @@\n" +
+		"-old\n" +
+		"+new\n" +
+		"diff --git a/main.go b/main.go\n" +
+		"index 6c61a60..85aa975 100644\n" +
+		"--- a/main.go\n" +
+		"+++ b/main.go\n" +
+		"@@ -1 +1 @@\n" +
+		"-old\n" +
+		"+new\n" +
+		"diff --git a/added.go b/added.go\n" +
+		"new file mode 100644\n" +
+		"index 0000000..ce01362\n" +
+		"--- /dev/null\n" +
+		"+++ b/added.go\n" +
+		"@@ -0,0 +1 @@\n" +
+		"+hello\n" +
+		"diff --git a/deleted.go b/deleted.go\n" +
+		"deleted file mode 100644\n" +
+		"index ce01362..0000000\n" +
+		"--- a/deleted.go\n" +
+		"+++ /dev/null\n" +
+		"@@ -1 +0,0 @@\n" +
+		"-hello\n"
+
+	l := &Labeler{GitHubFacade: &GitHubFacade{
+		GetRawDiff: func(owner, repo string, prNumber int) (string, error) { return diff, nil },
+	}}
+	ctx := l.newTargetContext(&Target{ghPR: &gh.PullRequest{}})
+	files, err := ctx.ChangedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []ChangedFile{
+		{Path: "new.go", PreviousPath: "old.go", Status: FileStatusRenamed},
+		{Path: "docs/b.md", PreviousPath: "docs/a.md", Status: FileStatusRenamed},
+		{Path: "main.go", Status: FileStatusModified},
+		{Path: "added.go", Status: FileStatusAdded},
+		{Path: "deleted.go", Status: FileStatusDeleted},
+	}
+	if !reflect.DeepEqual(expected, files) {
+		t.Errorf("Expected files %+v, got %+v", expected, files)
+	}
+}
+
+func TestChecksIgnoreCurrentWorkflowRun(t *testing.T) {
+	payload, err := loadPayload("create_pr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := LabelerConfigV1{
+		Version: 1,
+		Labels: []LabelMatcher{
+			{
+				Label:  "BuildGreen",
+				Checks: &ChecksConfig{State: "success", Names: []string{"^build$", "^labeler$"}},
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		runID    int64
+		expected []string
+	}{
+		{runID: 0, expected: []string{}},
+		{runID: 4321, expected: []string{}},
+		{runID: 1234, expected: []string{"BuildGreen"}},
+	} {
+		l := NewTestLabeler(t, TestCase{config: config})
+		l.WorkflowRunID = tc.runID
+		if err := l.HandleEvent("pull_request", &payload); err != nil {
+			t.Fatal(err)
+		}
+		assertLabels(t, l, tc.expected)
+	}
+}
+
+type httpClientFunc func(req *http.Request) (*http.Response, error)
+
+func (f httpClientFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRawDiffThroughHttpClient(t *testing.T) {
+	diff := "diff --git a/main.go b/main.go\n" +
+		"index 6c61a60..85aa975 100644\n" +
+		"--- a/main.go\n" +
+		"+++ b/main.go\n" +
+		"@@ -1 +1 @@\n" +
+		"-old\n" +
+		"+new\n"
+
+	requests := []string{}
+	l := &Labeler{
+		GitHubFacade: &GitHubFacade{},
+		Client: httpClientFunc(func(req *http.Request) (*http.Response, error) {
+			requests = append(requests, req.URL.String()+" "+req.Header.Get("Accept"))
+			return &http.Response{
+				Status:     "200 OK",
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(diff)),
+			}, nil
+		}),
+	}
+	url := "https://api.github.com/repos/srvaroa/labeler/pulls/1"
+	ctx := l.newTargetContext(&Target{ghPR: &gh.PullRequest{URL: gh.String(url)}})
+	files, err := ctx.ChangedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectRequests := []string{url + " application/vnd.github.v3.diff"}
+	if !reflect.DeepEqual(expectRequests, requests) {
+		t.Errorf("Expected requests %v, got %v", expectRequests, requests)
+	}
+	expectFiles := []ChangedFile{{Path: "main.go", Status: FileStatusModified}}
+	if !reflect.DeepEqual(expectFiles, files) {
+		t.Errorf("Expected files %+v, got %+v", expectFiles, files)
+	}
+}
@@ -0,0 +1,127 @@
+package labeler
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Status of a condition in the explanation of a matcher
+const (
+	ConditionEvaluated = "evaluated"
+	// The condition does not support the target (e.g. a PR-only
+	// condition on an issue)
+	ConditionSkipped = "skipped"
+	// The condition failed to evaluate, it is ignored in the result
+	ConditionFailed = "error"
+)
+
+// ExplanationMarker identifies comments that contain an explanation, so
+// that they can be updated instead of posting new ones.
+const ExplanationMarker = "<!-- labeler-explanation -->"
+
+// MatcherResult explains the outcome of evaluating a label matcher on a
+// target.
+type MatcherResult struct {
+	// Label is empty in nested matchers and label generators
+	Label string `json:"label,omitempty"`
+	// Block is the type of nested block (all, any or not) that contains
+	// the matcher, empty in top level matchers
+	Block string `json:"block,omitempty"`
+	// Evaluated is false when none of the conditions in the matcher
+	// could be evaluated on the target, so the label is left untouched.
+	Evaluated bool `json:"evaluated"`
+	Matched   bool `json:"matched"`
+	Negated   bool `json:"negated,omitempty"`
+	// Excluded is the label that was kept instead of this one in a
+	// group of mutually exclusive labels
+	Excluded string `json:"excluded,omitempty"`
+	// Generated are the labels emitted by a matcher without a label
+	Generated  []string          `json:"generated,omitempty"`
+	Conditions []ConditionResult `json:"conditions,omitempty"`
+	Nested     []MatcherResult   `json:"nested,omitempty"`
+}
+
+// ConditionResult explains the evaluation of a single condition.
+// Conditions that were not reached because the result of the matcher
+// was already known are not included.
+type ConditionResult struct {
+	Condition string `json:"condition"`
+	Status    string `json:"status"`
+	// Inputs contains the values observed by the condition
+	Inputs  []string `json:"inputs,omitempty"`
+	Matched bool     `json:"matched"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// FormatExplanation renders a human readable explanation of the plan in
+// Markdown, suitable for a comment in the PR or issue.
+func FormatExplanation(plan *LabelPlan) string {
+	var b strings.Builder
+	b.WriteString(ExplanationMarker + "\n")
+	b.WriteString("### Labeler explanation\n\n")
+	if len(plan.Add) > 0 {
+		fmt.Fprintf(&b, "Labels added: %s\n\n", formatLabels(plan.Add))
+	}
+	if len(plan.Remove) > 0 {
+		fmt.Fprintf(&b, "Labels removed: %s\n\n", formatLabels(plan.Remove))
+	}
+	if len(plan.Add) == 0 && len(plan.Remove) == 0 {
+		b.WriteString("No changes to labels.\n\n")
+	}
+	for _, m := range plan.Matchers {
+		if m.Label == "" {
+			fmt.Fprintf(&b, "* Label generator %s\n", describeMatcherResult(m))
+		} else {
+			fmt.Fprintf(&b, "* `%s` %s\n", m.Label, describeMatcherResult(m))
+		}
+		writeMatcherDetails(&b, m, "  ")
+	}
+	return b.String()
+}
+
+func formatLabels(labels []string) string {
+	quoted := make([]string, len(labels))
+	for i, label := range labels {
+		quoted[i] = "`" + label + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func describeMatcherResult(m MatcherResult) string {
+	result := "did not match"
+	if !m.Evaluated {
+		result = "was not evaluated (no condition applies)"
+	} else if m.Matched {
+		result = "matched"
+	}
+	if m.Negated {
+		result += " (negated)"
+	}
+	if len(m.Generated) > 0 {
+		result += fmt.Sprintf(", generating %s", formatLabels(m.Generated))
+	}
+	if m.Excluded != "" {
+		result += fmt.Sprintf(", but `%s` is kept instead", m.Excluded)
+	}
+	return result
+}
+
+func writeMatcherDetails(b *strings.Builder, m MatcherResult, indent string) {
+	for _, c := range m.Conditions {
+		switch c.Status {
+		case ConditionSkipped:
+			fmt.Fprintf(b, "%s* %s: skipped, not supported on this target\n", indent, c.Condition)
+		case ConditionFailed:
+			fmt.Fprintf(b, "%s* %s: error, %s\n", indent, c.Condition, c.Error)
+		default:
+			fmt.Fprintf(b, "%s* %s: %t\n", indent, c.Condition, c.Matched)
+		}
+		for _, input := range c.Inputs {
+			fmt.Fprintf(b, "%s  * %s\n", indent, input)
+		}
+	}
+	for _, nested := range m.Nested {
+		fmt.Fprintf(b, "%s* `%s` block %s\n", indent, nested.Block, describeMatcherResult(nested))
+		writeMatcherDetails(b, nested, indent+"  ")
+	}
+}
@@ -0,0 +1,110 @@
+package labeler
+
+import (
+	"testing"
+)
+
+func TestFormatExplanation(t *testing.T) {
+	payload, err := loadPayload("issue_open")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var plan *LabelPlan
+	l := NewTestLabeler(t, TestCase{
+		config: LabelerConfigV1{
+			Version: 1,
+			Issues:  true,
+			Labels: []LabelMatcher{
+				{
+					Label: "triage",
+					Title: "^Testy",
+					Any: []LabelMatcher{
+						{Branch: "^fix/.*"},
+						{Authors: []string{"someone"}},
+					},
+				},
+				{
+					Label:  "not-wip",
+					Negate: true,
+					Title:  "^WIP",
+				},
+				{
+					Label: "docs",
+					Files: &FilesConfig{Any: []string{"^docs/.*"}},
+				},
+			},
+		},
+		initialLabels: []string{"triage"},
+	})
+	l.DryRun = true
+	l.ReportPlan = func(p *LabelPlan) {
+		plan = p
+	}
+
+	err = l.HandleEvent("issues", &payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := ExplanationMarker + "\n" +
+		"### Labeler explanation\n\n" +
+		"Labels added: `not-wip`\n\n" +
+		"Labels removed: `triage`\n\n" +
+		"* `triage` did not match\n" +
+		"  * Title matches regex: true\n" +
+		"    * Matching `^Testy` against: `Testy test`\n" +
+		"  * `any` block was not evaluated (no condition applies)\n" +
+		"    * Branch matches regex: skipped, not supported on this target\n" +
+		"  * `any` block did not match\n" +
+		"    * Author matches: false\n" +
+		"      * Matching `[someone]` against: `srvaroa`\n" +
+		"* `not-wip` matched (negated)\n" +
+		"  * Title matches regex: false\n" +
+		"    * Matching `^WIP` against: `Testy test`\n" +
+		"* `docs` was not evaluated (no condition applies)\n" +
+		"  * Files match: skipped, not supported on this target\n"
+
+	got := FormatExplanation(plan)
+	if expect != got {
+		t.Fatalf("\nExpect:\n%s\nGot:\n%s", expect, got)
+	}
+}
+
+func TestFormatExplanationExcludedByGroup(t *testing.T) {
+	plan := &LabelPlan{
+		Add:    []string{"size/L"},
+		Remove: []string{},
+		Matchers: []MatcherResult{
+			{Label: "size/M", Evaluated: true, Matched: true, Excluded: "size/L"},
+			{Label: "size/L", Evaluated: true, Matched: true},
+		},
+	}
+
+	expect := ExplanationMarker + "\n" +
+		"### Labeler explanation\n\n" +
+		"Labels added: `size/L`\n\n" +
+		"* `size/M` matched, but `size/L` is kept instead\n" +
+		"* `size/L` matched\n"
+	if got := FormatExplanation(plan); got != expect {
+		t.Fatalf("\nExpect:\n%s\nGot:\n%s", expect, got)
+	}
+}
+
+func TestFormatExplanationLabelGenerator(t *testing.T) {
+	plan := &LabelPlan{
+		Add:    []string{"area/api", "area/docs"},
+		Remove: []string{},
+		Matchers: []MatcherResult{
+			{Evaluated: true, Matched: true, Generated: []string{"area/api", "area/docs"}},
+		},
+	}
+
+	expect := ExplanationMarker + "\n" +
+		"### Labeler explanation\n\n" +
+		"Labels added: `area/api`, `area/docs`\n\n" +
+		"* Label generator matched, generating `area/api`, `area/docs`\n"
+	if got := FormatExplanation(plan); got != expect {
+		t.Fatalf("\nExpect:\n%s\nGot:\n%s", expect, got)
+	}
+}
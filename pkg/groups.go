@@ -0,0 +1,93 @@
+package labeler
+
+import (
+	"fmt"
+	"log"
+)
+
+// Strategies to choose the label that is kept when several labels in a
+// group match
+const (
+	// The label of the first matcher in the config that matched
+	GroupStrategyFirst = "first"
+	// The label of the last matcher in the config that matched
+	GroupStrategyLast = "last"
+	// The label that comes first in the list of labels of the group
+	GroupStrategyPriority = "priority"
+)
+
+// LabelGroup declares labels that are mutually exclusive, so that at
+// most one of them is set on a target.
+type LabelGroup struct {
+	Name   string
+	Labels []string
+	// Strategy decides which label wins when several match, defaults
+	// to "first"
+	Strategy string
+}
+
+// applyGroups keeps a single matched label in each group, setting the
+// rest of labels in the group to be removed.
+func applyGroups(labelUpdates *LabelUpdates, groups []LabelGroup) error {
+	for _, group := range groups {
+		winner := ""
+		switch group.Strategy {
+		case "", GroupStrategyFirst, GroupStrategyLast:
+			for _, m := range labelUpdates.matchers {
+				if !contains(group.Labels, m.Label) || !m.Matched {
+					continue
+				}
+				if winner == "" || group.Strategy == GroupStrategyLast {
+					winner = m.Label
+				}
+			}
+		case GroupStrategyPriority:
+			for _, label := range group.Labels {
+				if labelUpdates.set[label] {
+					winner = label
+					break
+				}
+			}
+		default:
+			return fmt.Errorf("invalid strategy `%s` in group %s", group.Strategy, group.Name)
+		}
+		if winner == "" {
+			continue
+		}
+
+		for _, label := range group.Labels {
+			if label == winner {
+				continue
+			}
+			if labelUpdates.set[label] {
+				log.Printf("Label %s is excluded by %s in group %s", label, winner, group.Name)
+			}
+			labelUpdates.set[label] = false
+			labelUpdates.excluded[label] = true
+		}
+		for i, m := range labelUpdates.matchers {
+			if m.Label != winner && m.Matched && contains(group.Labels, m.Label) {
+				labelUpdates.matchers[i].Excluded = winner
+			}
+		}
+	}
+	return nil
+}
+
+func contains(labels []string, label string) bool {
+	for _, l := range labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+func validateGroupStrategy(value string) error {
+	switch value {
+	case GroupStrategyFirst, GroupStrategyLast, GroupStrategyPriority:
+		return nil
+	}
+	return fmt.Errorf("must be `%s`, `%s` or `%s`",
+		GroupStrategyFirst, GroupStrategyLast, GroupStrategyPriority)
+}
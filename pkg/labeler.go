@@ -1,7 +1,13 @@
 package labeler
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
 	"log"
+	"reflect"
+	"sort"
 	"strings"
 
 	gh "github.com/google/go-github/v50/github"
@@ -19,21 +25,41 @@ type SizeConfig struct {
 }
 
 type LabelMatcher struct {
-	Age            string          `yaml:"age,omitempty"` // Deprecated age config.
-	AgeRange       *DurationConfig `yaml:"age-range,omitempty"`
+	Age      string          `yaml:"age,omitempty"` // Deprecated age config.
+	AgeRange *DurationConfig `yaml:"age-range,omitempty"`
+	// All, Any and Not nest further matchers (without a label) that
+	// are combined with the conditions in this matcher, so that rules
+	// can express arbitrary boolean expressions.
+	All            []LabelMatcher
+	Any            []LabelMatcher
+	Assignees      *AssigneesConfig
 	AuthorCanMerge string `yaml:"author-can-merge"`
 	Authors        []string
 	AuthorInTeam   string `yaml:"author-in-team"`
 	BaseBranch     string `yaml:"base-branch"`
 	Body           string
 	Branch         string
-	Draft          string
-	Files          []string
-	Label          string
-	LastModified   *DurationConfig `yaml:"last-modified"`
-	Mergeable      string
-	Negate         bool
-	Size           *SizeConfig
+	Checks         *ChecksConfig
+	CodeOwners     *CodeOwnersConfig
+	Commits        *CommitsConfig
+	// Color and Description of the label, used to create or update it
+	// in the repository when syncing labels
+	Color        string
+	Description  string
+	Diff         *DiffConfig
+	Draft        string
+	Files        *FilesConfig
+	HasAssignee  string `yaml:"has-assignee"`
+	Label        string
+	Labels       *LabelsConfig
+	LastModified *DurationConfig     `yaml:"last-modified"`
+	LinkedIssues *LinkedIssuesConfig `yaml:"linked-issues"`
+	Mergeable    string
+	Milestone    string
+	Negate       bool
+	Not          *LabelMatcher
+	Reviews      *ReviewsConfig
+	Size         *SizeConfig
 	// size-legacy
 	// These two are unused in the codebase (they get copied inside
 	// the Size object), but we keep them to respect backwards
@@ -44,6 +70,9 @@ type LabelMatcher struct {
 	// size-legacy
 	Title string
 	Type  string
+	// Extensions holds the configuration of conditions registered with
+	// RegisterCondition, indexed by their key
+	Extensions map[string]interface{} `yaml:",inline"`
 }
 
 type LabelerConfigV0 map[string]LabelMatcher
@@ -57,35 +86,108 @@ type LabelerConfigV1 struct {
 	// but it will NOT remove labels that were previously set and stop
 	// matching a rule
 	AppendOnly bool `yaml:"appendOnly"`
-	Labels     []LabelMatcher
+	// ManagedLabels declares the labels owned by the labeler, as exact
+	// names, globs like `size/*` or regexes prefixed with `regex:`.
+	// Owned labels that no matcher sets are removed from targets, even
+	// if their matcher was deleted from the config.
+	ManagedLabels []string `yaml:"managed-labels"`
+	// Groups declare labels that are mutually exclusive
+	Groups []LabelGroup
+	Labels []LabelMatcher
 }
 
 // LabelUpdates Represents a request to update the set of labels
 type LabelUpdates struct {
-	set map[string]bool
+	set      map[string]bool
+	matchers []MatcherResult
+	// excluded are the labels removed by groups in favour of another
+	// label in the same group
+	excluded map[string]bool
+	// partial is true when some label generator could not be evaluated
+	// on the target, so labels missing from set may still be desired
+	partial bool
 }
 
 // Just to make this mockable..
+//
+// List functions return iterators over all the items, fetching further
+// pages as they are consumed. When a page can't be fetched, they yield
+// the error and stop.
 type GitHubFacade struct {
-	GetRawDiff         func(owner, repo string, prNumber int) (string, error)
-	GetPR              func(owner, repo string, prNumber int) (*gh.PullRequest, error)
-	ListIssuesByRepo   func(owner, repo string) ([]*gh.Issue, error)
-	ListPRs            func(owner, repo string) ([]*gh.PullRequest, error)
+	GetRawDiff       func(owner, repo string, prNumber int) (string, error)
+	GetPR            func(owner, repo string, prNumber int) (*gh.PullRequest, error)
+	GetIssue         func(owner, repo string, issueNo int) (*gh.Issue, error)
+	GetFileContent   func(owner, repo, path, ref string) (string, error)
+	ListIssuesByRepo func(owner, repo string) iter.Seq2[*gh.Issue, error]
+	ListPRs          func(owner, repo string) iter.Seq2[*gh.PullRequest, error]
+	ListReviews      func(owner, repo string, prNumber int) iter.Seq2[*gh.PullRequestReview, error]
+	ListCommits      func(owner, repo string, prNumber int) iter.Seq2[*gh.RepositoryCommit, error]
+	// ListRequestedReviewers returns the reviewers whose review is
+	// pending on the PR
+	ListRequestedReviewers func(owner, repo string, prNumber int) (*gh.Reviewers, error)
+	// ListStatuses returns the latest status of each context on a
+	// commit, and ListCheckRuns its check runs
+	ListStatuses  func(owner, repo, ref string) iter.Seq2[*gh.RepoStatus, error]
+	ListCheckRuns func(owner, repo, ref string) iter.Seq2[*gh.CheckRun, error]
+	// ListPRsForCommit returns the PRs that contain a commit
+	ListPRsForCommit   func(owner, repo, sha string) iter.Seq2[*gh.PullRequest, error]
+	ListRepoLabels     func(owner, repo string) iter.Seq2[*gh.Label, error]
+	CreateLabel        func(owner, repo string, label *gh.Label) error
+	EditLabel          func(owner, repo, name string, label *gh.Label) error
 	IsUserMemberOfTeam func(org, user, team string) (bool, error)
 }
 
+// LabelPlan describes the changes to labels that an execution intends
+// to make on a target.
+type LabelPlan struct {
+	Owner   string   `json:"owner"`
+	Repo    string   `json:"repo"`
+	IssueNo int      `json:"number"`
+	Add     []string `json:"add"`
+	Remove  []string `json:"remove"`
+	// Matchers explains the result of each matcher that was evaluated
+	Matchers []MatcherResult `json:"matchers,omitempty"`
+}
+
 type Labeler struct {
-	FetchRepoConfig  func() (*LabelerConfigV1, error)
-	ReplaceLabels    func(target *Target, labels []string) error
+	FetchRepoConfig func() (*LabelerConfigV1, error)
+	// AddLabels and RemoveLabels modify only the given labels, so
+	// that changes made by others since we read the current labels
+	// are preserved
+	AddLabels        func(target *Target, labels []string) error
+	RemoveLabels     func(target *Target, labels []string) error
 	GetCurrentLabels func(target *Target) ([]string, error)
 	GitHubFacade     *GitHubFacade
-	Client           HttpClient
+	// Client makes the requests that don't go through the GitHubFacade,
+	// like downloading the diff of a PR when GitHubFacade.GetRawDiff is
+	// not set
+	Client HttpClient
+	// When set to true, labels are computed and reported but never
+	// modified.
+	DryRun bool
+	// ReportPlan receives the plan computed for each target, including
+	// the explanation of each matcher. When nil, plans are logged.
+	ReportPlan func(plan *LabelPlan)
+	// Concurrency is the number of targets processed in parallel by
+	// ProcessAllPRs and ProcessAllIssues. Values below 2 process
+	// targets one after another.
+	Concurrency int
+	// WorkflowRunID is the ID of the workflow run executing the labeler,
+	// if any. Its check runs are ignored by the checks condition, as
+	// they are still in progress while the labeler runs.
+	WorkflowRunID int64
 }
 
 type Condition struct {
 	CanEvaluate func(target *Target) bool
-	Evaluate    func(target *Target, matcher LabelMatcher) (bool, error)
+	Evaluate    func(target *TargetContext, matcher LabelMatcher) (bool, error)
 	GetName     func() string
+	// Keys in the matcher config that are read by the condition. The
+	// condition is only evaluated on matchers that set any of them.
+	Keys []string
+	// GenerateLabels is optional, and returns the labels that matchers
+	// without a label emit when they match
+	GenerateLabels func(target *TargetContext, matcher LabelMatcher) ([]string, error)
 }
 
 type Target struct {
@@ -95,8 +197,22 @@ type Target struct {
 	Title    string
 	Owner    string
 	RepoName string
-	ghPR     *gh.PullRequest
-	ghIssue  *gh.Issue
+	// Milestone is the title of the milestone of the target, empty when
+	// it has none
+	Milestone string
+	Assignees []string
+	ghPR      *gh.PullRequest
+	ghIssue   *gh.Issue
+	// values observed by the condition being evaluated
+	observations []string
+}
+
+// Observe logs a value seen by a condition while evaluating the target,
+// which is also recorded in the explanation of the matcher.
+func (t *Target) Observe(format string, args ...interface{}) {
+	observation := fmt.Sprintf(format, args...)
+	log.Print(observation)
+	t.observations = append(t.observations, observation)
 }
 
 // HandleEvent takes a GitHub Event and its raw payload (see link below)
@@ -126,22 +242,110 @@ func (l *Labeler) HandleEvent(
 			return nil
 		}
 		err = l.ExecuteOn(wrapIssueAsTarget(event.Issue))
+	case *gh.IssueCommentEvent:
+		if event.GetAction() != "created" || !isRefreshCommand(event.GetComment().GetBody()) {
+			log.Printf("Comment is not a %s command, ignoring", RefreshCommand)
+			return nil
+		}
+		if event.GetIssue().IsPullRequest() {
+			err = l.executeOnPR(event.GetRepo(), event.GetIssue().GetNumber())
+			break
+		}
+		config, cfgErr := l.FetchRepoConfig()
+		if cfgErr != nil {
+			return cfgErr
+		}
+		if !config.Issues {
+			log.Println("Issues must be explicitly enabled in order to process issues in event mode")
+			return nil
+		}
+		err = l.ExecuteOn(wrapIssueAsTarget(event.Issue))
+	case *gh.PullRequestReviewEvent:
+		// The PR in review payloads may be stale, e.g. after new pushes
+		err = l.executeOnPR(event.GetRepo(), event.GetPullRequest().GetNumber())
+	case *gh.PullRequestReviewCommentEvent:
+		err = l.executeOnPR(event.GetRepo(), event.GetPullRequest().GetNumber())
+	case *gh.CheckSuiteEvent:
+		if event.GetAction() != "completed" {
+			log.Printf("Check suite is %s, waiting until it completes", event.GetAction())
+			return nil
+		}
+		errs := []error{}
+		for _, pr := range event.GetCheckSuite().PullRequests {
+			errs = append(errs, l.executeOnPR(event.GetRepo(), pr.GetNumber()))
+		}
+		err = errors.Join(errs...)
+	case *gh.CheckRunEvent:
+		if event.GetAction() != "completed" {
+			log.Printf("Check run is %s, waiting until it completes", event.GetAction())
+			return nil
+		}
+		errs := []error{}
+		for _, pr := range event.GetCheckRun().PullRequests {
+			errs = append(errs, l.executeOnPR(event.GetRepo(), pr.GetNumber()))
+		}
+		err = errors.Join(errs...)
+	case *gh.StatusEvent:
+		if event.GetState() == "pending" {
+			log.Printf("Status %s is pending, waiting until it completes", event.GetContext())
+			return nil
+		}
+		err = l.executeOnCommit(event.GetRepo(), event.GetSHA())
 	default:
 		log.Printf("Event type is not supported, please review your workflow config")
 	}
 	return err
 }
 
+// RefreshCommand is the comment that triggers the labeler on the PR or
+// issue where it's posted
+const RefreshCommand = "/label-refresh"
+
+func isRefreshCommand(comment string) bool {
+	return strings.TrimSpace(comment) == RefreshCommand
+}
+
+// executeOnPR fetches the PR from GitHub and updates its labels, used for
+// events whose payloads don't contain the full PR
+func (l *Labeler) executeOnPR(repo *gh.Repository, prNumber int) error {
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+	log.Printf("Fetching PR %s/%s#%d", owner, name, prNumber)
+	pr, err := l.GitHubFacade.GetPR(owner, name, prNumber)
+	if err != nil {
+		return fmt.Errorf("unable to fetch PR %s/%s#%d: %w", owner, name, prNumber, err)
+	}
+	return l.ExecuteOn(wrapPrAsTarget(pr))
+}
+
+// executeOnCommit updates the labels of the open PRs that contain a
+// commit, used for status events which don't reference PRs
+func (l *Labeler) executeOnCommit(repo *gh.Repository, sha string) error {
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+	errs := []error{}
+	for pr, err := range l.GitHubFacade.ListPRsForCommit(owner, name, sha) {
+		if err != nil {
+			return fmt.Errorf("unable to list PRs for commit %s: %w", sha, err)
+		}
+		if pr.GetState() != "open" {
+			continue
+		}
+		errs = append(errs, l.executeOnPR(repo, pr.GetNumber()))
+	}
+	return errors.Join(errs...)
+}
+
 func wrapPrAsTarget(pr *gh.PullRequest) *Target {
 	return &Target{
-		Author:   *pr.GetUser().Login,
-		Body:     pr.GetBody(),
-		IssueNo:  *pr.Number,
-		Title:    pr.GetTitle(),
-		Owner:    pr.Base.Repo.GetOwner().GetLogin(),
-		RepoName: *pr.Base.Repo.Name,
-		ghPR:     pr,
-		ghIssue:  nil,
+		Author:    *pr.GetUser().Login,
+		Body:      pr.GetBody(),
+		IssueNo:   *pr.Number,
+		Title:     pr.GetTitle(),
+		Owner:     pr.Base.Repo.GetOwner().GetLogin(),
+		RepoName:  *pr.Base.Repo.Name,
+		Milestone: pr.GetMilestone().GetTitle(),
+		Assignees: userLogins(pr.Assignees),
+		ghPR:      pr,
+		ghIssue:   nil,
 	}
 }
 
@@ -154,18 +358,34 @@ func wrapIssueAsTarget(issue *gh.Issue) *Target {
 	owner := repoUrlSplit[len(repoUrlSplit)-2]
 
 	return &Target{
-		Author:   *issue.GetUser().Login,
-		Body:     issue.GetBody(),
-		IssueNo:  *issue.Number,
-		Title:    issue.GetTitle(),
-		Owner:    owner,
-		RepoName: repoName,
-		ghPR:     nil,
-		ghIssue:  issue,
+		Author:    *issue.GetUser().Login,
+		Body:      issue.GetBody(),
+		IssueNo:   *issue.Number,
+		Title:     issue.GetTitle(),
+		Owner:     owner,
+		RepoName:  repoName,
+		Milestone: issue.GetMilestone().GetTitle(),
+		Assignees: userLogins(issue.Assignees),
+		ghPR:      nil,
+		ghIssue:   issue,
 	}
 }
 
+func userLogins(users []*gh.User) []string {
+	logins := []string{}
+	for _, user := range users {
+		logins = append(logins, user.GetLogin())
+	}
+	return logins
+}
+
 func (l *Labeler) ExecuteOn(target *Target) error {
+	return l.executeOn(target, l.reportPlan)
+}
+
+// executeOn updates the labels of the target, passing the computed plan
+// to report before any label is modified
+func (l *Labeler) executeOn(target *Target, report func(plan *LabelPlan)) error {
 
 	log.Printf("Matching labels on target %+v", target)
 
@@ -175,14 +395,15 @@ func (l *Labeler) ExecuteOn(target *Target) error {
 		return err
 	}
 
-	labelUpdates, err := l.findMatches(target, config)
+	// current labels are fetched first, as conditions may depend on them
+	currLabels, err := l.GetCurrentLabels(target)
 	if err != nil {
-		log.Printf("Unable to find matches %+v", err)
 		return err
 	}
 
-	currLabels, err := l.GetCurrentLabels(target)
+	labelUpdates, err := l.findMatches(target, config, currLabels)
 	if err != nil {
+		log.Printf("Unable to find matches %+v", err)
 		return err
 	}
 
@@ -197,12 +418,16 @@ func (l *Labeler) ExecuteOn(target *Target) error {
 	log.Printf("Current labels: `%v`", intentions)
 	log.Printf("Preliminary label updates: `%v`", labelUpdates)
 	if config.AppendOnly {
-		log.Printf("AppendOnly is active, removals are forbidden")
+		log.Printf("AppendOnly is active, removals are forbidden except for groups")
 	}
 	// update, adding new ones and unflagging those to remove if
 	// necessary
 	for label, isDesired := range labelUpdates.set {
-		if config.AppendOnly {
+		if config.AppendOnly && labelUpdates.excluded[label] {
+			// Groups remove the labels that exclude each other
+			// even when deletions are not allowed
+			intentions[label] = false
+		} else if config.AppendOnly {
 			// If we DO NOT allow deletions, then we will respect
 			// labels that were already set in the current set
 			// but add new ones that matched the repo
@@ -213,44 +438,125 @@ func (l *Labeler) ExecuteOn(target *Target) error {
 			intentions[label] = isDesired
 		}
 	}
+
+	// remove stale labels owned by the labeler that no matcher can set,
+	// e.g. because their matcher was removed from the config
+	managed, err := managedLabels(config)
+	if err != nil {
+		return err
+	}
+	if labelUpdates.partial {
+		log.Printf("Some label generators were not evaluated, managed labels are kept")
+	}
+	for _, label := range currLabels {
+		if _, ok := labelUpdates.set[label]; ok || config.AppendOnly || labelUpdates.partial || hasMatcher(config, label) {
+			continue
+		}
+		if isManagedLabel(managed, label) {
+			log.Printf("Label `%s` is managed but no matcher sets it, removing", label)
+			intentions[label] = false
+		}
+	}
 	log.Printf("Final labels: `%v`", intentions)
 
-	// filter out only labels that must be set
-	desiredLabels := []string{}
-	for k, v := range intentions {
-		if v {
-			desiredLabels = append(desiredLabels, k)
+	plan := newLabelPlan(target, currLabels, intentions)
+	plan.Matchers = labelUpdates.matchers
+	report(plan)
+
+	if l.DryRun {
+		log.Printf("Dry run, labels will not be modified")
+		return nil
+	}
+
+	return l.applyPlan(target, plan)
+}
+
+// applyPlan adds and removes the labels in the plan, without making any
+// call if there is nothing to change
+func (l *Labeler) applyPlan(target *Target, plan *LabelPlan) error {
+	if len(plan.Add) == 0 && len(plan.Remove) == 0 {
+		log.Printf("Labels are up to date")
+		return nil
+	}
+	if len(plan.Add) > 0 {
+		log.Printf("Adding labels: `%q`", plan.Add)
+		if err := l.AddLabels(target, plan.Add); err != nil {
+			return err
+		}
+	}
+	if len(plan.Remove) > 0 {
+		log.Printf("Removing labels: `%q`", plan.Remove)
+		if err := l.RemoveLabels(target, plan.Remove); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newLabelPlan computes the labels that must be added and removed on the
+// target to go from its current labels to the intended ones
+func newLabelPlan(target *Target, currLabels []string, intentions map[string]bool) *LabelPlan {
+	plan := &LabelPlan{
+		Owner:   target.Owner,
+		Repo:    target.RepoName,
+		IssueNo: target.IssueNo,
+		Add:     []string{},
+		Remove:  []string{},
+	}
+
+	current := map[string]bool{}
+	for _, label := range currLabels {
+		current[label] = true
+	}
+
+	for label, isDesired := range intentions {
+		if isDesired && !current[label] {
+			plan.Add = append(plan.Add, label)
+		} else if !isDesired && current[label] {
+			plan.Remove = append(plan.Remove, label)
 		}
 	}
-	log.Printf("Final set of labels: `%q`", desiredLabels)
+	sort.Strings(plan.Add)
+	sort.Strings(plan.Remove)
 
-	return l.ReplaceLabels(target, desiredLabels)
+	return plan
 }
 
-// findMatches returns all updates to be made to labels for the given target
-func (l *Labeler) findMatches(target *Target, config *LabelerConfigV1) (LabelUpdates, error) {
+func (l *Labeler) reportPlan(plan *LabelPlan) {
+	if l.ReportPlan != nil {
+		l.ReportPlan(plan)
+		return
+	}
+	raw, err := json.Marshal(plan)
+	if err != nil {
+		log.Printf("Unable to serialize plan %+v: %s", plan, err)
+		return
+	}
+	log.Printf("Planned label changes: %s", raw)
+}
+
+// findMatches returns all updates to be made to labels for the given
+// target. Matchers are evaluated in the order of the config, so that the
+// labels condition sees the decisions of earlier matchers over the
+// current labels of the target.
+func (l *Labeler) findMatches(target *Target, config *LabelerConfigV1, currLabels []string) (LabelUpdates, error) {
 
 	labelUpdates := LabelUpdates{
-		set: map[string]bool{},
-	}
-	conditions := []Condition{
-		AgeCondition(l),
-		AuthorCondition(),
-		AuthorCanMergeCondition(),
-		AuthorInTeamCondition(l),
-		BaseBranchCondition(),
-		BodyCondition(),
-		BranchCondition(),
-		FilesCondition(l),
-		LastModifiedCondition(l),
-		IsDraftCondition(),
-		IsMergeableCondition(),
-		SizeCondition(l),
-		TitleCondition(),
-		TypeCondition(),
+		set:      map[string]bool{},
+		excluded: map[string]bool{},
 	}
+	conditions := getConditions()
+	ctx := l.newTargetContext(target)
+	ctx.currentLabels = currLabels
+	ctx.updates = &labelUpdates
+	ctx.appendOnly = config.AppendOnly
 
 	for _, matcher := range config.Labels {
+		if matcher.Label == "" {
+			l.generateLabels(ctx, matcher, conditions, &labelUpdates)
+			continue
+		}
+
 		label := matcher.Label
 		log.Printf("Evaluating label %s", label)
 
@@ -268,95 +574,249 @@ func (l *Labeler) findMatches(target *Target, config *LabelerConfigV1) (LabelUpd
 		// condition
 		delete(labelUpdates.set, label)
 
+		matcherResult := MatcherResult{Label: label}
+		isMatched, isEvaluated := l.evaluateMatcher(ctx, matcher, conditions, &matcherResult)
+		if isEvaluated {
+			labelUpdates.set[label] = isMatched
+		}
+
+		if matcher.Negate {
+			result, _ := labelUpdates.set[label]
+			labelUpdates.set[label] = !result
+			log.Printf("[%s] is negated from %t", label, result)
+		}
+
+		matcherResult.Matched, matcherResult.Evaluated = labelUpdates.set[label]
+		matcherResult.Negated = matcher.Negate
+		labelUpdates.matchers = append(labelUpdates.matchers, matcherResult)
+	}
+
+	if err := applyGroups(&labelUpdates, config.Groups); err != nil {
+		return labelUpdates, err
+	}
+
+	return labelUpdates, nil
+}
+
+// generateLabels sets the labels emitted by the conditions of a matcher
+// without a label (e.g. copied from linked issues) when it matches.
+// Generated labels are only added, never removed, unless they are
+// managed labels.
+func (l *Labeler) generateLabels(target *TargetContext, matcher LabelMatcher, conditions []Condition, labelUpdates *LabelUpdates) {
+	log.Printf("Evaluating label generator")
+	result := MatcherResult{}
+	result.Matched, result.Evaluated = l.evaluateMatcher(target, matcher, conditions, &result)
+	if !result.Evaluated {
+		labelUpdates.partial = true
+	}
+	if result.Matched {
 		for _, c := range conditions {
-			if !c.CanEvaluate(target) {
-				log.Printf("[%s] skip, event not supported by condition", c.GetName())
+			if c.GenerateLabels == nil || !isConditionSet(c, matcher) {
 				continue
 			}
-			isMatched, err := c.Evaluate(target, matcher)
+			if !c.CanEvaluate(target.Target) {
+				labelUpdates.partial = true
+				continue
+			}
+			labels, err := c.GenerateLabels(target, matcher)
 			if err != nil {
-				log.Printf("[%s] skip, %s", c.GetName(), err)
+				log.Printf("[%s] unable to generate labels, %s", c.GetName(), err)
+				labelUpdates.partial = true
 				continue
 			}
-			log.Printf("[%s] yields %t", c.GetName(), isMatched)
+			for _, label := range labels {
+				log.Printf("[%s] generates label %s", c.GetName(), label)
+				labelUpdates.set[label] = true
+				if !contains(result.Generated, label) {
+					result.Generated = append(result.Generated, label)
+				}
+			}
+		}
+	}
+	labelUpdates.matchers = append(labelUpdates.matchers, result)
+}
+
+// matcherFields are the fields of LabelMatcher indexed by their config
+// key, to find the keys set in a matcher without walking its type again
+var matcherFields = yamlFields(reflect.TypeOf(LabelMatcher{}))
+
+// isConditionSet tells whether the matcher sets any of the keys read by
+// the condition. Conditions without keys are always evaluated.
+func isConditionSet(c Condition, matcher LabelMatcher) bool {
+	if len(c.Keys) == 0 {
+		return true
+	}
+	value := reflect.ValueOf(matcher)
+	for _, key := range c.Keys {
+		if field, ok := matcherFields[key]; ok && !value.FieldByIndex(field.Index).IsZero() {
+			return true
+		}
+		if _, ok := matcher.Extensions[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluateMatcher evaluates the conditions in the matcher, combined with
+// an AND, together with its nested all / any / not blocks. Evaluation
+// short-circuits as soon as the result is known. The explanation of the
+// evaluation is recorded in result.
+//
+// The second value is false when no condition could be evaluated on the
+// target (e.g. all of them are PR-only and the target is an issue), in
+// which case the result carries no opinion about the label.
+func (l *Labeler) evaluateMatcher(target *TargetContext, matcher LabelMatcher, conditions []Condition, result *MatcherResult) (bool, bool) {
+	isEvaluated := false
+
+	for _, c := range conditions {
+		if !isConditionSet(c, matcher) {
+			continue
+		}
+		if !c.CanEvaluate(target.Target) {
+			log.Printf("[%s] skip, event not supported by condition", c.GetName())
+			result.Conditions = append(result.Conditions, ConditionResult{
+				Condition: c.GetName(),
+				Status:    ConditionSkipped,
+			})
+			continue
+		}
+		target.observations = nil
+		isMatched, err := c.Evaluate(target, matcher)
+		conditionResult := ConditionResult{
+			Condition: c.GetName(),
+			Status:    ConditionEvaluated,
+			Inputs:    target.observations,
+			Matched:   isMatched,
+		}
+		if err != nil {
+			log.Printf("[%s] skip, %s", c.GetName(), err)
+			conditionResult.Status = ConditionFailed
+			conditionResult.Error = err.Error()
+			result.Conditions = append(result.Conditions, conditionResult)
+			continue
+		}
+		log.Printf("[%s] yields %t", c.GetName(), isMatched)
+		result.Conditions = append(result.Conditions, conditionResult)
+		if !isMatched {
+			return false, true
+		}
+		isEvaluated = true
+	}
+
+	for _, nested := range matcher.All {
+		isMatched, ok := l.evaluateNestedMatcher(target, "all", nested, conditions, result)
+		if !ok {
+			continue
+		}
+		if !isMatched {
+			log.Printf("[all] yields false")
+			return false, true
+		}
+		isEvaluated = true
+	}
 
-			prev, ok := labelUpdates.set[label]
-			if ok { // Other conditions were evaluated for the label
-				labelUpdates.set[label] = prev && isMatched
-			} else { // First condition evaluated for this label
-				labelUpdates.set[label] = isMatched
+	if len(matcher.Any) > 0 {
+		anyEvaluated, anyMatched := false, false
+		for _, nested := range matcher.Any {
+			isMatched, ok := l.evaluateNestedMatcher(target, "any", nested, conditions, result)
+			if !ok {
+				continue
+			}
+			anyEvaluated = true
+			if isMatched {
+				anyMatched = true
+				break
 			}
 		}
+		if anyEvaluated {
+			log.Printf("[any] yields %t", anyMatched)
+			if !anyMatched {
+				return false, true
+			}
+			isEvaluated = true
+		}
+	}
 
-		if matcher.Negate {
-			result, _ := labelUpdates.set[label]
-			labelUpdates.set[label] = !result
-			log.Printf("[%s] is negated from %t", label, result)
+	if matcher.Not != nil {
+		isMatched, ok := l.evaluateNestedMatcher(target, "not", *matcher.Not, conditions, result)
+		if ok {
+			log.Printf("[not] yields %t", !isMatched)
+			if isMatched {
+				return false, true
+			}
+			isEvaluated = true
 		}
 	}
 
-	return labelUpdates, nil
+	return isEvaluated, isEvaluated
+}
+
+// evaluateNestedMatcher evaluates a matcher inside an all / any / not
+// block. Unlike top level matchers, negate only applies when the nested
+// matcher could be evaluated.
+func (l *Labeler) evaluateNestedMatcher(target *TargetContext, block string, matcher LabelMatcher, conditions []Condition, parent *MatcherResult) (bool, bool) {
+	result := MatcherResult{Block: block, Negated: matcher.Negate}
+	isMatched, ok := l.evaluateMatcher(target, matcher, conditions, &result)
+	if ok && matcher.Negate {
+		isMatched = !isMatched
+	}
+	result.Evaluated, result.Matched = ok, isMatched
+	parent.Nested = append(parent.Nested, result)
+	return isMatched, ok
 }
 
-func (l *Labeler) ProcessAllIssues(owner, repo string) {
+// ProcessAllIssues updates the labels of all open issues in the
+// repository, if issues are enabled in the config. Results are returned
+// in the order issues were listed.
+func (l *Labeler) ProcessAllIssues(owner, repo string) ([]TargetResult, error) {
 
 	config, err := l.FetchRepoConfig()
 	if err != nil {
 		log.Printf("Unable to load configuration %+v", err)
-		return
+		return nil, err
 	}
 
 	if !config.Issues {
 		log.Println("Issues must be explicitly enabled in order to process issues in the scheduled execution mode")
-		return
-	}
-
-	issues, err := l.GitHubFacade.ListIssuesByRepo(owner, repo)
-
-	if err != nil {
-		log.Printf("Unable to list issues in %s/%s: %+v", owner, repo, err)
-		return
+		return nil, nil
 	}
 
-	for _, issue := range issues {
+	targets := []*Target{}
+	for issue, err := range l.GitHubFacade.ListIssuesByRepo(owner, repo) {
+		if err != nil {
+			log.Printf("Unable to list issues in %s/%s: %+v", owner, repo, err)
+			return nil, err
+		}
 		if issue.State != nil && strings.ToLower(*issue.State) != "open" {
 			continue
 		}
-		err = l.ExecuteOn(wrapIssueAsTarget(issue))
-		if err != nil {
-			log.Printf("Unable to execute action on issue #%d: %+v", issue.GetNumber(), err)
+		if issue.IsPullRequest() {
+			// PRs are listed as issues too, but processed by ProcessAllPRs
+			continue
 		}
+		targets = append(targets, wrapIssueAsTarget(issue))
 	}
+	return l.processTargets("issue", targets), nil
 }
 
-func (l *Labeler) ProcessAllPRs(owner, repo string) {
-
-	prs, err := l.GitHubFacade.ListPRs(owner, repo)
+// ProcessAllPRs updates the labels of all open PRs in the repository.
+// Results are returned in the order PRs were listed.
+func (l *Labeler) ProcessAllPRs(owner, repo string) ([]TargetResult, error) {
 
-	if err != nil {
-		log.Printf("Unable to list pull requests in %s/%s: %+v", owner, repo, err)
-		return
-	}
-
-	for _, pr := range prs {
+	targets := []*Target{}
+	for pr, err := range l.GitHubFacade.ListPRs(owner, repo) {
+		if err != nil {
+			log.Printf("Unable to list pull requests in %s/%s: %+v", owner, repo, err)
+			return nil, err
+		}
 		if pr.State != nil && strings.ToLower(*pr.State) != "open" {
 			continue
 		}
 		// The List endpoint does not populate fields like
-		// additions/deletions. We need to fetch each PR
-		// individually to get full details (see #173).
-		if l.GitHubFacade.GetPR != nil {
-			fullPR, err := l.GitHubFacade.GetPR(owner, repo, pr.GetNumber())
-			if err != nil {
-				log.Printf("Unable to get full PR #%d: %+v", pr.GetNumber(), err)
-			} else {
-				pr = fullPR
-			}
-		}
-		err = l.ExecuteOn(wrapPrAsTarget(pr))
-		if err != nil {
-			log.Printf("Unable to execute action on PR #%d: %+v", pr.GetNumber(), err)
-		}
+		// additions/deletions. Conditions that need them will fetch
+		// the full PR through the TargetContext (see #173).
+		targets = append(targets, wrapPrAsTarget(pr))
 	}
-
+	return l.processTargets("PR", targets), nil
 }
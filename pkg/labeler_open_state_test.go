@@ -1,6 +1,7 @@
 package labeler
 
 import (
+	"iter"
 	"testing"
 
 	gh "github.com/google/go-github/v50/github"
@@ -25,19 +26,18 @@ func TestProcessAllIssuesAndPRsOnlyOpen(t *testing.T) {
 		FetchRepoConfig: func() (*LabelerConfigV1, error) {
 			return &LabelerConfigV1{Version: 1, Issues: true, Labels: []LabelMatcher{}}, nil
 		},
-		ReplaceLabels: func(target *Target, labels []string) error {
+		GetCurrentLabels: func(target *Target) ([]string, error) {
 			if target.ghIssue != nil {
 				calls.issues = append(calls.issues, target.IssueNo)
 			}
 			if target.ghPR != nil {
 				calls.prs = append(calls.prs, target.IssueNo)
 			}
-			return nil
+			return nil, nil
 		},
-		GetCurrentLabels: func(target *Target) ([]string, error) { return nil, nil },
 		GitHubFacade: &GitHubFacade{
-			ListIssuesByRepo: func(owner, repo string) ([]*gh.Issue, error) { return fakeIssues, nil },
-			ListPRs:          func(owner, repo string) ([]*gh.PullRequest, error) { return fakePRs, nil },
+			ListIssuesByRepo: func(owner, repo string) iter.Seq2[*gh.Issue, error] { return listOf(fakeIssues) },
+			ListPRs:          func(owner, repo string) iter.Seq2[*gh.PullRequest, error] { return listOf(fakePRs) },
 		},
 	}
 
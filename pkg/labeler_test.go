@@ -1,9 +1,10 @@
 package labeler
 
 import (
+	"encoding/json"
 	"fmt"
-	"io"
 	"io/ioutil"
+	"iter"
 	"net/http"
 	"os"
 	"reflect"
@@ -23,6 +24,39 @@ func loadPayload(name string) ([]byte, error) {
 	return ioutil.ReadAll(file)
 }
 
+// listOf fakes a paginated list endpoint that returns the items
+func listOf[T any](items []T) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for _, item := range items {
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
+
+// loadResponse decodes the JSON response of the GitHub API in test_data
+func loadResponse(name string, v interface{}) error {
+	data, err := ioutil.ReadFile("../test_data/" + name + "_response")
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// listResponse fakes a paginated list endpoint that returns the items in
+// the JSON response in test_data
+func listResponse[T any](name string) iter.Seq2[T, error] {
+	var items []T
+	if err := loadResponse(name, &items); err != nil {
+		return func(yield func(T, error) bool) {
+			var zero T
+			yield(zero, err)
+		}
+	}
+	return listOf(items)
+}
+
 type TestCase struct {
 	event          string // issues or pull_request
 	payloads       []string
@@ -49,19 +83,30 @@ func TestProcessAllIssues(t *testing.T) {
 			Labels:        []*gh.Label{},
 			RepositoryURL: gh.String("https://api.github.com/repos/srvaroa/labeler"),
 		},
+		{
+			// PRs are also listed as issues
+			Number:           gh.Int(2),
+			Title:            gh.String("Testy test"),
+			User:             &gh.User{Login: gh.String("srvaroa")},
+			State:            gh.String("open"),
+			CreatedAt:        &gh.Timestamp{Time: time.Now()},
+			Labels:           []*gh.Label{},
+			RepositoryURL:    gh.String("https://api.github.com/repos/srvaroa/labeler"),
+			PullRequestLinks: &gh.PullRequestLinks{URL: gh.String("https://api.github.com/repos/srvaroa/labeler/pulls/2")},
+		},
 	}
 
 	makeLabeler := func(cfg LabelerConfigV1, calls *[]call) Labeler {
 		return Labeler{
 			FetchRepoConfig:  func() (*LabelerConfigV1, error) { return &cfg, nil },
 			GetCurrentLabels: func(target *Target) ([]string, error) { return []string{"ShouldStay"}, nil },
-			ReplaceLabels: func(target *Target, labels []string) error {
-				*calls = append(*calls, call{issueNo: target.IssueNo, labels: labels})
+			AddLabels: func(target *Target, labels []string) error {
+				*calls = append(*calls, call{issueNo: target.IssueNo, labels: append([]string{"ShouldStay"}, labels...)})
 				return nil
 			},
 			GitHubFacade: &GitHubFacade{
-				ListIssuesByRepo: func(owner, repo string) ([]*gh.Issue, error) {
-					return fakeIssues, nil
+				ListIssuesByRepo: func(owner, repo string) iter.Seq2[*gh.Issue, error] {
+					return listOf(fakeIssues)
 				},
 			},
 		}
@@ -101,6 +146,9 @@ func TestProcessAllIssues(t *testing.T) {
 		if len(calls) != 1 {
 			t.Errorf("Expected 1 issue processed, got %d", len(calls))
 		}
+		if len(calls) > 0 && calls[0].issueNo != 1 {
+			t.Errorf("Expected issue 1 processed, got %d", calls[0].issueNo)
+		}
 		if len(calls) > 0 {
 			expected := []string{"ShouldStay", "Test"}
 			got := calls[0].labels
@@ -683,9 +731,9 @@ func TestHandleEvent(t *testing.T) {
 				Labels: []LabelMatcher{
 					{
 						Label: "Files",
-						Files: []string{
+						Files: &FilesConfig{Any: []string{
 							"^.*.md",
-						},
+						}},
 					},
 				},
 			},
@@ -701,9 +749,9 @@ func TestHandleEvent(t *testing.T) {
 				Labels: []LabelMatcher{
 					{
 						Label: "Files",
-						Files: []string{
+						Files: &FilesConfig{Any: []string{
 							"(.*?)\\/(sub|test)\\/.*",
-						},
+						}},
 					},
 				},
 			},
@@ -719,10 +767,10 @@ func TestHandleEvent(t *testing.T) {
 				Labels: []LabelMatcher{
 					{
 						Label: "Book",
-						Files: []string{
+						Files: &FilesConfig{Any: []string{
 							// matches root/sub/test.md only
 							"root\\/.+\\/.+.md",
-						},
+						}},
 					},
 				},
 			},
@@ -738,10 +786,10 @@ func TestHandleEvent(t *testing.T) {
 				Labels: []LabelMatcher{
 					{
 						Label: "BookStyle",
-						Files: []string{
+						Files: &FilesConfig{Any: []string{
 							// matches sub/test.md only
 							"^sub\\/.+.md",
-						},
+						}},
 					},
 				},
 			},
@@ -757,10 +805,10 @@ func TestHandleEvent(t *testing.T) {
 				Labels: []LabelMatcher{
 					{
 						Label: "BookStyle",
-						Files: []string{
+						Files: &FilesConfig{Any: []string{
 							// matches README.md, not the two above
 							"^.+.md",
-						},
+						}},
 					},
 				},
 			},
@@ -952,6 +1000,7 @@ func TestHandleEvent(t *testing.T) {
 			name:     "Add a label to issue when title matches",
 			config: LabelerConfigV1{
 				Version: 1,
+				Issues:  true,
 				Labels: []LabelMatcher{
 					{
 						Label: "Test",
@@ -968,6 +1017,7 @@ func TestHandleEvent(t *testing.T) {
 			name:     "Remove a label from issue when title does not match",
 			config: LabelerConfigV1{
 				Version: 1,
+				Issues:  true,
 				Labels: []LabelMatcher{
 					{
 						Label: "Test",
@@ -984,6 +1034,7 @@ func TestHandleEvent(t *testing.T) {
 			name:     "Add label to issue when author matches",
 			config: LabelerConfigV1{
 				Version: 1,
+				Issues:  true,
 				Labels: []LabelMatcher{
 					{
 						Label:   "Test",
@@ -1000,6 +1051,7 @@ func TestHandleEvent(t *testing.T) {
 			name:     "Remove label from issue when author does not match",
 			config: LabelerConfigV1{
 				Version: 1,
+				Issues:  true,
 				Labels: []LabelMatcher{
 					{
 						Label:   "Test",
@@ -1016,6 +1068,7 @@ func TestHandleEvent(t *testing.T) {
 			name:     "Add label to issue when body matches",
 			config: LabelerConfigV1{
 				Version: 1,
+				Issues:  true,
 				Labels: []LabelMatcher{
 					{
 						Label: "Test",
@@ -1032,6 +1085,7 @@ func TestHandleEvent(t *testing.T) {
 			name:     "Remove label from issue when body does not match",
 			config: LabelerConfigV1{
 				Version: 1,
+				Issues:  true,
 				Labels: []LabelMatcher{
 					{
 						Label: "Test",
@@ -1060,9 +1114,9 @@ func TestHandleEvent(t *testing.T) {
 					},
 					{
 						Label: "ShouldNotAppear3",
-						Files: []string{
+						Files: &FilesConfig{Any: []string{
 							"^.*.md",
-						},
+						}},
 					},
 					{
 						Label:  "ShouldNotAppear4",
@@ -1115,6 +1169,7 @@ func TestHandleEvent(t *testing.T) {
 			name:     "Add a label to issue when type matches (issues eq issue)",
 			config: LabelerConfigV1{
 				Version: 1,
+				Issues:  true,
 				Labels: []LabelMatcher{
 					{
 						Label: "Test",
@@ -1165,6 +1220,7 @@ func TestHandleEvent(t *testing.T) {
 			name:     "Add a label to issue when author is in team",
 			config: LabelerConfigV1{
 				Version: 1,
+				Issues:  true,
 				Labels: []LabelMatcher{
 					{
 						Label:        "ShouldAppear",
@@ -1215,86 +1271,1135 @@ func TestHandleEvent(t *testing.T) {
 			initialLabels:  []string{},
 			expectedLabels: []string{},
 		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			for _, file := range tc.payloads {
-				payload, err := loadPayload(file)
-				if err != nil {
-					fmt.Printf("Test `%s`: failed to load %s\n", tc.name, file)
-					t.Fatal(err)
-				}
-				fmt.Printf("Loaded payload: %s\n", string(payload))
-
-				fmt.Printf("--> TEST: %s \n", tc.name)
-				l := NewTestLabeler(t, tc)
-				err = l.HandleEvent(tc.event, &payload)
-				if err != nil {
-					fmt.Printf("Test failed: %s\n", tc.name)
-					t.Fatal(err)
-				}
-			}
-		})
-	}
-}
-
-func NewTestLabeler(t *testing.T, tc TestCase) Labeler {
-	return Labeler{
-		FetchRepoConfig: func() (*LabelerConfigV1, error) {
-			return &tc.config, nil
+		{
+			event:    "pull_request",
+			payloads: []string{"create_pr"},
+			name:     "Nested all / any blocks combine conditions",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{
+						Label: "ShouldAppear",
+						Files: &FilesConfig{Any: []string{"^README.md$"}},
+						Any: []LabelMatcher{
+							{AuthorInTeam: "team-without-author"},
+							{Title: "^WIP:.*"},
+						},
+					},
+					{
+						Label: "ShouldNotAppear",
+						All: []LabelMatcher{
+							{Files: &FilesConfig{Any: []string{"^README.md$"}}},
+							{Title: "^WOP:.*"},
+						},
+					},
+					{
+						Label: "ShouldNotAppear2",
+						Any: []LabelMatcher{
+							{Authors: []string{"someone-else"}},
+							{Branch: "^feature/.*"},
+						},
+					},
+				},
+			},
+			initialLabels:  []string{"ShouldNotAppear2"},
+			expectedLabels: []string{"ShouldAppear"},
 		},
-		GetCurrentLabels: func(target *Target) ([]string, error) {
-			return tc.initialLabels, nil
+		{
+			event:    "pull_request",
+			payloads: []string{"create_pr"},
+			name:     "Nested not block negates its conditions",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{
+						Label: "ShouldAppear",
+						Title: "^WIP:.*",
+						Not:   &LabelMatcher{Branch: "^feature/.*"},
+					},
+					{
+						Label: "ShouldNotAppear",
+						Not: &LabelMatcher{
+							Any: []LabelMatcher{
+								{Authors: []string{"srvaroa"}},
+								{Branch: "^feature/.*"},
+							},
+						},
+					},
+					{
+						Label: "ShouldAppear2",
+						All: []LabelMatcher{
+							{Negate: true, Title: "^WOP:.*"},
+							{Authors: []string{"srvaroa"}},
+						},
+					},
+				},
+			},
+			initialLabels:  []string{"ShouldNotAppear"},
+			expectedLabels: []string{"ShouldAppear", "ShouldAppear2"},
 		},
-		ReplaceLabels: func(target *Target, labels []string) error {
-			sort.Strings(tc.expectedLabels)
-			sort.Strings(labels)
-			if reflect.DeepEqual(tc.expectedLabels, labels) {
-				return nil
-			}
-			return fmt.Errorf("%s: Expecting %+v, got %+v",
-				tc.name, tc.expectedLabels, labels)
+		{
+			event:    "issues",
+			payloads: []string{"issue_open"},
+			name:     "Nested blocks ignore conditions that can't evaluate the target",
+			config: LabelerConfigV1{
+				Version: 1,
+				Issues:  true,
+				Labels: []LabelMatcher{
+					{
+						Label: "ShouldAppear",
+						Any: []LabelMatcher{
+							{Files: &FilesConfig{Any: []string{".*"}}},
+							{Title: "^Testy.*"},
+						},
+					},
+					{
+						Label: "ShouldStay",
+						Any: []LabelMatcher{
+							{Files: &FilesConfig{Any: []string{".*"}}},
+							{Branch: ".*"},
+						},
+					},
+				},
+			},
+			initialLabels:  []string{"ShouldStay"},
+			expectedLabels: []string{"ShouldAppear", "ShouldStay"},
 		},
-		Client: &FakeHttpClient{},
-		GitHubFacade: &GitHubFacade{
-			GetRawDiff: func(owner, repo string, prNumber int) (string, error) {
-				file, err := os.Open("../test_data/diff_response")
-				if err != nil {
-					return "", err
-				}
-
-				data, err := ioutil.ReadAll(file)
-				return string(data), nil
+		{
+			event:    "pull_request",
+			payloads: []string{"create_pr"},
+			name:     "Managed labels without a matcher are removed",
+			config: LabelerConfigV1{
+				Version:       1,
+				ManagedLabels: []string{"size/*", "regex:area/.+", "stale"},
+				Labels: []LabelMatcher{
+					{
+						Label: "WIP",
+						Title: "^WIP:.*",
+					},
+				},
 			},
-			// Will return true whenever team contains the given user name
-			IsUserMemberOfTeam: func(org, user, team string) (bool, error) {
-				return strings.Contains(team, user), nil
+			initialLabels:  []string{"size/XL", "area/docs", "stale", "sizeable", "size/x/y", "Untouched"},
+			expectedLabels: []string{"WIP", "sizeable", "size/x/y", "Untouched"},
+		},
+		{
+			event:    "pull_request",
+			payloads: []string{"create_pr"},
+			name:     "Managed labels are not removed in append only mode",
+			config: LabelerConfigV1{
+				Version:       1,
+				AppendOnly:    true,
+				ManagedLabels: []string{"size/*"},
+				Labels: []LabelMatcher{
+					{
+						Label: "WIP",
+						Title: "^WIP:.*",
+					},
+				},
 			},
+			initialLabels:  []string{"size/XL"},
+			expectedLabels: []string{"WIP", "size/XL"},
 		},
+		{
+			event:    "issues",
+			payloads: []string{"issue_open"},
+			name:     "Managed labels with a matcher that doesn't apply are kept",
+			config: LabelerConfigV1{
+				Version:       1,
+				Issues:        true,
+				ManagedLabels: []string{"size/*"},
+				Labels: []LabelMatcher{
+					{
+						Label:     "size/XL",
+						SizeAbove: "100",
+					},
+				},
+			},
+			initialLabels:  []string{"size/XL", "size/S"},
+			expectedLabels: []string{"size/XL"},
+		},
+		{
+			event:    "pull_request",
+			payloads: []string{"create_pr"},
+			name:     "First matched label wins in a group",
+			config: LabelerConfigV1{
+				Version: 1,
+				Groups: []LabelGroup{
+					{Name: "kind", Labels: []string{"A", "B", "C"}},
+				},
+				Labels: []LabelMatcher{
+					{Label: "A", Title: "^WIP"},
+					{Label: "B", Title: "test$"},
+					{Label: "C", Title: "^Nope"},
+					{Label: "D", Title: "^WIP"},
+				},
+			},
+			initialLabels:  []string{"C", "Untouched"},
+			expectedLabels: []string{"A", "D", "Untouched"},
+		},
+		{
+			event:    "pull_request",
+			payloads: []string{"create_pr"},
+			name:     "Last matched label wins in a group",
+			config: LabelerConfigV1{
+				Version: 1,
+				Groups: []LabelGroup{
+					{Name: "kind", Labels: []string{"A", "B", "C"}, Strategy: "last"},
+				},
+				Labels: []LabelMatcher{
+					{Label: "A", Title: "^WIP"},
+					{Label: "B", Title: "test$"},
+					{Label: "C", Title: "^Nope"},
+				},
+			},
+			initialLabels:  []string{"A"},
+			expectedLabels: []string{"B"},
+		},
+		{
+			event:    "pull_request",
+			payloads: []string{"create_pr"},
+			name:     "Label with the highest priority wins in a group",
+			config: LabelerConfigV1{
+				Version: 1,
+				Groups: []LabelGroup{
+					{Name: "kind", Labels: []string{"C", "B", "A"}, Strategy: "priority"},
+				},
+				Labels: []LabelMatcher{
+					{Label: "A", Title: "^WIP"},
+					{Label: "B", Title: "test$"},
+					{Label: "C", Title: "^Nope"},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{"B"},
+		},
+		{
+			event:    "issues",
+			payloads: []string{"issue_open"},
+			name:     "Groups leave labels untouched when none matches",
+			config: LabelerConfigV1{
+				Version: 1,
+				Issues:  true,
+				Groups: []LabelGroup{
+					{Name: "size", Labels: []string{"size/S", "size/L"}},
+				},
+				Labels: []LabelMatcher{
+					{Label: "size/S", SizeBelow: "10"},
+					{Label: "size/L", SizeAbove: "100"},
+				},
+			},
+			initialLabels:  []string{"size/L"},
+			expectedLabels: []string{"size/L"},
+		},
+		{
+			event:    "issue_comment",
+			payloads: []string{"issue_comment_pr"},
+			name:     "Refresh labels of a PR when commenting /label-refresh",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "WIP", Title: "^WIP:.*"},
+					{Label: "Stale", Title: "^Stale.*"},
+				},
+			},
+			initialLabels:  []string{"Stale"},
+			expectedLabels: []string{"WIP"},
+		},
+		{
+			event:    "issue_comment",
+			payloads: []string{"issue_comment_issue"},
+			name:     "Refresh labels of an issue when commenting /label-refresh",
+			config: LabelerConfigV1{
+				Version: 1,
+				Issues:  true,
+				Labels: []LabelMatcher{
+					{Label: "Test", Title: "^Testy.*t"},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{"Test"},
+		},
+		{
+			event:    "issue_comment",
+			payloads: []string{"issue_comment_ignored"},
+			name:     "Ignore comments other than /label-refresh",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "WIP", Title: "^WIP:.*"},
+				},
+			},
+			initialLabels:  []string{"Stale"},
+			expectedLabels: []string{"Stale"},
+		},
+		{
+			event:    "pull_request_review",
+			payloads: []string{"pull_request_review"},
+			name:     "Refresh labels of a PR when a review is submitted",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "WIP", Title: "^WIP:.*"},
+					{Label: "Stale", Title: "^Stale.*"},
+				},
+			},
+			initialLabels:  []string{"Stale"},
+			expectedLabels: []string{"WIP"},
+		},
+		{
+			event:    "pull_request_review_comment",
+			payloads: []string{"pull_request_review_comment"},
+			name:     "Refresh labels of a PR when a review comment is posted",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "WIP", Title: "^WIP:.*"},
+					{Label: "Stale", Title: "^Stale.*"},
+				},
+			},
+			initialLabels:  []string{"Stale"},
+			expectedLabels: []string{"WIP"},
+		},
+		{
+			event:    "check_suite",
+			payloads: []string{"check_suite"},
+			name:     "Refresh labels of the PRs of a completed check suite",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "WIP", Title: "^WIP:.*"},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{"WIP"},
+		},
+		{
+			event:    "pull_request",
+			payloads: []string{"create_pr"},
+			name:     "Add labels based on the reviews of a PR",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{
+						Label:   "Approved",
+						Reviews: &ReviewsConfig{Approvals: &CountConfig{AtLeast: "2"}},
+					},
+					{
+						Label:   "TooManyApprovals",
+						Reviews: &ReviewsConfig{Approvals: &CountConfig{AtLeast: "3"}},
+					},
+					{
+						Label:   "NotApproved",
+						Reviews: &ReviewsConfig{Approvals: &CountConfig{AtMost: "0"}},
+					},
+					{
+						Label:   "ChangesRequested",
+						Reviews: &ReviewsConfig{ChangesRequested: "true"},
+					},
+					{
+						Label:   "NeedsReview",
+						Reviews: &ReviewsConfig{RequestedReviewersPending: "true"},
+					},
+					{
+						Label:   "ApprovedByBob",
+						Reviews: &ReviewsConfig{ApprovedByTeam: "team-with-bob"},
+					},
+					{
+						Label:   "ApprovedByCarol",
+						Reviews: &ReviewsConfig{ApprovedByTeam: "team-with-carol"},
+					},
+					{
+						Label: "ReadyToMerge",
+						Reviews: &ReviewsConfig{
+							Approvals:        &CountConfig{AtLeast: "1"},
+							ChangesRequested: "false",
+						},
+					},
+				},
+			},
+			initialLabels:  []string{"NotApproved", "ReadyToMerge"},
+			expectedLabels: []string{"Approved", "ChangesRequested", "NeedsReview", "ApprovedByBob"},
+		},
+		{
+			event:    "issues",
+			payloads: []string{"issue_open"},
+			name:     "Reviews conditions don't apply to issues",
+			config: LabelerConfigV1{
+				Version: 1,
+				Issues:  true,
+				Labels: []LabelMatcher{
+					{
+						Label:   "ChangesRequested",
+						Reviews: &ReviewsConfig{ChangesRequested: "false"},
+					},
+				},
+			},
+			initialLabels:  []string{"ChangesRequested"},
+			expectedLabels: []string{"ChangesRequested"},
+		},
+		{
+			event:    "pull_request",
+			payloads: []string{"create_pr"},
+			name:     "Add labels based on the checks of a PR",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "CIFailing", Checks: &ChecksConfig{State: "failure"}},
+					{Label: "CIGreen", Checks: &ChecksConfig{State: "success"}},
+					{
+						Label:  "BuildPassed",
+						Checks: &ChecksConfig{State: "success", Names: []string{"^build$"}},
+					},
+					{
+						Label:  "CIPending",
+						Checks: &ChecksConfig{State: "pending", Names: []string{"e2e", "^ci/"}},
+					},
+					{
+						Label:  "JenkinsGreen",
+						Checks: &ChecksConfig{State: "Success", Names: []string{"^ci/jenkins$"}},
+					},
+					{Label: "Deployed", Checks: &ChecksConfig{Names: []string{"deploy"}}},
+					{Label: "Linted", Checks: &ChecksConfig{Names: []string{"lint"}}},
+				},
+			},
+			initialLabels:  []string{"CIGreen"},
+			expectedLabels: []string{"CIFailing", "BuildPassed", "CIPending", "JenkinsGreen", "Linted"},
+		},
+		{
+			event:    "check_run",
+			payloads: []string{"check_run"},
+			name:     "Refresh labels of the PRs of a completed check run",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "CIFailing", Checks: &ChecksConfig{State: "failure"}},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{"CIFailing"},
+		},
+		{
+			event:    "status",
+			payloads: []string{"status"},
+			name:     "Refresh labels of the open PRs that contain the commit of a status",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "WIP", Title: "^WIP:.*"},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{"WIP"},
+		},
+		{
+			event:    "status",
+			payloads: []string{"status_pending"},
+			name:     "Pending statuses don't refresh labels",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "WIP", Title: "^WIP:.*"},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{},
+		},
+		{
+			event:    "pull_request",
+			payloads: []string{"linked_issues_pr"},
+			name:     "Add labels based on the issues linked to a PR",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "FixesBug", LinkedIssues: &LinkedIssuesConfig{Labels: []string{"^bug$"}}},
+					{Label: "ClosesClosedIssue", LinkedIssues: &LinkedIssuesConfig{State: "closed"}},
+					{Label: "Milestone2", LinkedIssues: &LinkedIssuesConfig{Milestone: "^v2\\."}},
+					{Label: "Milestone3", LinkedIssues: &LinkedIssuesConfig{Milestone: "^v3\\."}},
+					{
+						Label:        "OpenDocs",
+						LinkedIssues: &LinkedIssuesConfig{State: "open", Labels: []string{"^area/docs$"}},
+					},
+					{
+						Label:        "LinksPR",
+						LinkedIssues: &LinkedIssuesConfig{Labels: []string{"area/ignored"}},
+					},
+				},
+			},
+			initialLabels:  []string{"Milestone3", "OpenDocs"},
+			expectedLabels: []string{"FixesBug", "ClosesClosedIssue", "Milestone2"},
+		},
+		{
+			event:    "pull_request",
+			payloads: []string{"linked_issues_pr"},
+			name:     "Copy labels from the issues linked to a PR",
+			config: LabelerConfigV1{
+				Version:       1,
+				ManagedLabels: []string{"area/*"},
+				Labels: []LabelMatcher{
+					{LinkedIssues: &LinkedIssuesConfig{CopyLabels: []string{"area/*", "regex:priority/.+"}}},
+					{
+						LinkedIssues: &LinkedIssuesConfig{CopyLabels: []string{"wontfix"}, State: "open"},
+					},
+					{Label: "WIP", Title: "^WIP:.*"},
+				},
+			},
+			initialLabels:  []string{"area/api", "area/stale"},
+			expectedLabels: []string{"area/api", "area/docs", "priority/high", "WIP"},
+		},
+		{
+			event:    "pull_request",
+			payloads: []string{"create_pr"},
+			name:     "Managed labels copied from linked issues are removed when no issue is linked",
+			config: LabelerConfigV1{
+				Version:       1,
+				ManagedLabels: []string{"area/*"},
+				Labels: []LabelMatcher{
+					{LinkedIssues: &LinkedIssuesConfig{CopyLabels: []string{"area/*", "priority/*"}}},
+					{Label: "FixesBug", LinkedIssues: &LinkedIssuesConfig{Labels: []string{"^bug$"}}},
+				},
+			},
+			initialLabels:  []string{"area/api", "priority/high", "FixesBug"},
+			expectedLabels: []string{"priority/high"},
+		},
+		{
+			event:    "pull_request",
+			payloads: []string{"assigned_pr"},
+			name:     "Add labels based on the milestone and assignees of a PR",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "v2", Milestone: "^v2\\."},
+					{Label: "NoMilestone", Milestone: "none"},
+					{Label: "Assigned", HasAssignee: "true"},
+					{Label: "Unassigned", HasAssignee: "false"},
+					{Label: "AssignedToAlice", Assignees: &AssigneesConfig{Any: []string{"Alice"}}},
+					{Label: "NotAssignedToDave", Assignees: &AssigneesConfig{None: []string{"dave"}}},
+					{Label: "NotAssignedToBob", Assignees: &AssigneesConfig{None: []string{"bob"}}},
+					{Label: "AssignedToTeam", Assignees: &AssigneesConfig{Team: "team-with-bob"}},
+					{Label: "AssignedToFrontend", Assignees: &AssigneesConfig{Team: "frontend"}},
+					{
+						Label:     "AliceWithoutCarol",
+						Assignees: &AssigneesConfig{Any: []string{"carol", "alice"}, None: []string{"carol"}},
+					},
+				},
+			},
+			initialLabels:  []string{"NoMilestone", "Unassigned"},
+			expectedLabels: []string{"v2", "Assigned", "AssignedToAlice", "NotAssignedToDave", "AssignedToTeam", "AliceWithoutCarol"},
+		},
+		{
+			event:    "pull_request",
+			payloads: []string{"create_pr"},
+			name:     "Add labels to PRs without milestone or assignees",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "v2", Milestone: "^v2\\."},
+					{Label: "NoMilestone", Milestone: "None"},
+					{Label: "Unassigned", HasAssignee: "false"},
+					{Label: "NotAssignedToDave", Assignees: &AssigneesConfig{None: []string{"dave"}}},
+				},
+			},
+			initialLabels:  []string{"v2"},
+			expectedLabels: []string{"NoMilestone", "Unassigned", "NotAssignedToDave"},
+		},
+		{
+			event:    "issues",
+			payloads: []string{"assigned_issue"},
+			name:     "Add labels based on the milestone and assignees of an issue",
+			config: LabelerConfigV1{
+				Version: 1,
+				Issues:  true,
+				Labels: []LabelMatcher{
+					{Label: "Backlog", Milestone: "^Backlog$"},
+					{Label: "Assigned", HasAssignee: "true"},
+					{Label: "AssignedToCarol", Assignees: &AssigneesConfig{Any: []string{"carol"}}},
+				},
+			},
+			initialLabels:  []string{},
+			expectedLabels: []string{"Backlog", "Assigned", "AssignedToCarol"},
+		},
+		{
+			event:    "pull_request",
+			payloads: []string{"create_pr"},
+			name:     "Add labels based on the labels decided by earlier matchers",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "EarlyTriage", Labels: &LabelsConfig{HasNone: []string{"area/*"}}},
+					{Label: "area/api", Title: "^WIP:.*"},
+					{Label: "NeedsTriage", Labels: &LabelsConfig{HasNone: []string{"area/*"}}},
+					{Label: "Stale", Title: "^Stale"},
+					{Label: "WasStale", Labels: &LabelsConfig{HasAny: []string{"Stale"}}},
+					{
+						Label:      "Backport",
+						BaseBranch: "^master$",
+						Labels:     &LabelsConfig{HasAll: []string{"ReleaseBlocker", "area/*"}},
+					},
+					{Label: "Urgent", Labels: &LabelsConfig{HasAny: []string{"urgent", "regex:P[0-1]"}}},
+					{Label: "Routine", Labels: &LabelsConfig{HasAny: []string{"regex:P[2-4]"}}},
+				},
+			},
+			initialLabels:  []string{"NeedsTriage", "ReleaseBlocker", "P1", "Stale"},
+			expectedLabels: []string{"EarlyTriage", "area/api", "ReleaseBlocker", "P1", "Backport", "Urgent"},
+		},
+		{
+			event:    "pull_request",
+			payloads: []string{"create_pr"},
+			name:     "Labels kept in append only mode are seen by later matchers",
+			config: LabelerConfigV1{
+				Version:    1,
+				AppendOnly: true,
+				Labels: []LabelMatcher{
+					{Label: "Stale", Title: "^Stale"},
+					{Label: "WasStale", Labels: &LabelsConfig{HasAny: []string{"Stale"}}},
+				},
+			},
+			initialLabels:  []string{"Stale"},
+			expectedLabels: []string{"Stale", "WasStale"},
+		},
+		{
+			event:    "pull_request",
+			payloads: []string{"create_pr"},
+			name:     "Add labels based on the commits of a PR",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "NeedsSquash", Commits: &CommitsConfig{Count: &CountConfig{AtLeast: "3"}}},
+					{Label: "TooManyCommits", Commits: &CommitsConfig{Count: &CountConfig{AtLeast: "11"}}},
+					{Label: "Fixup", Commits: &CommitsConfig{Message: "^fixup!"}},
+					{Label: "Revert", Commits: &CommitsConfig{Message: "^Revert"}},
+					{Label: "Unsigned", Commits: &CommitsConfig{Unsigned: "true"}},
+					{Label: "Signed", Commits: &CommitsConfig{Unsigned: "false"}},
+					{Label: "CoAuthored", Commits: &CommitsConfig{MultipleAuthors: "true"}},
+					{
+						Label: "SmallFixup",
+						Commits: &CommitsConfig{
+							Count:   &CountConfig{AtMost: "3"},
+							Message: "^fixup!",
+						},
+					},
+				},
+			},
+			initialLabels:  []string{"Signed", "Revert"},
+			expectedLabels: []string{"NeedsSquash", "Fixup", "Unsigned", "CoAuthored", "SmallFixup"},
+		},
+		{
+			event:    "issues",
+			payloads: []string{"issue_open"},
+			name:     "Commits conditions don't apply to issues",
+			config: LabelerConfigV1{
+				Version: 1,
+				Issues:  true,
+				Labels: []LabelMatcher{
+					{Label: "Signed", Commits: &CommitsConfig{Unsigned: "false"}},
+				},
+			},
+			initialLabels:  []string{"Signed"},
+			expectedLabels: []string{"Signed"},
+		},
+		{
+			event:    "pull_request",
+			payloads: []string{"diff_pr"},
+			name:     "Add labels based on the lines added and removed in the diff",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "Exclaims", Diff: &DiffConfig{AddedLines: "!!$"}},
+					{
+						Label: "ExclaimsALot",
+						Diff:  &DiffConfig{AddedLines: "!!$", Count: &CountConfig{AtLeast: "4"}},
+					},
+					{
+						Label: "AddsLogs",
+						Diff:  &DiffConfig{AddedLines: "log\\.Printf", Files: []string{"\\.go$"}},
+					},
+					{
+						Label: "AddsLogsToDocs",
+						Diff:  &DiffConfig{AddedLines: "log\\.Printf", Files: []string{"\\.md$"}},
+					},
+					{
+						Label: "RemovesDependabot",
+						Diff:  &DiffConfig{RemovedLines: "package-ecosystem", Files: []string{"^dependabot\\.yml$"}},
+					},
+					{
+						Label: "RetitlesTwoFiles",
+						Diff: &DiffConfig{
+							AddedLines:   "^# Test File !$",
+							RemovedLines: "^# Test File$",
+							Count:        &CountConfig{AtLeast: "2", AtMost: "2"},
+						},
+					},
+					{
+						Label: "RetitlesOneFile",
+						Diff: &DiffConfig{
+							RemovedLines: "^# Test File$",
+							Count:        &CountConfig{AtMost: "1"},
+						},
+					},
+					{
+						Label: "NoTODOs",
+						Diff:  &DiffConfig{AddedLines: "TODO", Count: &CountConfig{AtMost: "0"}},
+					},
+				},
+			},
+			initialLabels:  []string{"AddsLogsToDocs"},
+			expectedLabels: []string{"Exclaims", "AddsLogs", "RemovesDependabot", "RetitlesTwoFiles", "NoTODOs"},
+		},
+		{
+			event:    "pull_request",
+			payloads: []string{"diff_pr"},
+			name:     "Match files and exclude them from the size with globs",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "Go", Files: &FilesConfig{Any: []string{"glob:*.go"}}},
+					{Label: "RootDocs", Files: &FilesConfig{Any: []string{"glob:/*.md"}}},
+					{Label: "NestedDocs", Files: &FilesConfig{Any: []string{"glob:**/sub/*.md", "glob:!/root/"}}},
+					{Label: "OnlyRootDocs", Files: &FilesConfig{Any: []string{"glob:*.md", "glob:!sub/"}}},
+					{Label: "Vendor", Files: &FilesConfig{Any: []string{"glob:vendor/"}}},
+					{
+						Label: "S",
+						Size: &SizeConfig{
+							ExcludeFiles: []string{"glob:*.md", "glob:*.yml", "glob:new_file"},
+							Below:        "2",
+						},
+					},
+					{
+						Label: "Code",
+						Diff:  &DiffConfig{AddedLines: "log\\.Printf", Files: []string{"glob:pkg/**"}},
+					},
+				},
+			},
+			initialLabels:  []string{"Vendor"},
+			expectedLabels: []string{"Go", "RootDocs", "NestedDocs", "OnlyRootDocs", "S", "Code"},
+		},
+		{
+			event:    "pull_request",
+			payloads: []string{"diff_pr"},
+			name:     "Add labels when any, all or none of the files match, by change type",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "DocsOnly", Files: &FilesConfig{All: []string{"glob:*.md"}}},
+					{Label: "NoDocs", Files: &FilesConfig{None: []string{"glob:*.md"}}},
+					{Label: "NoVendor", Files: &FilesConfig{None: []string{"glob:vendor/"}}},
+					{
+						Label: "DocsAndCode",
+						Files: &FilesConfig{All: []string{"glob:*.md", "glob:*.go", "glob:*.yml", "glob:new_file"}},
+					},
+					{Label: "AddsFiles", Files: &FilesConfig{Status: []string{"added"}}},
+					{Label: "RenamesFiles", Files: &FilesConfig{Status: []string{"renamed"}}},
+					{
+						Label: "RemovesConfig",
+						Files: &FilesConfig{Any: []string{"glob:*.yml"}, Status: []string{"Deleted"}},
+					},
+					{
+						Label: "AddsConfig",
+						Files: &FilesConfig{Any: []string{"glob:*.yml"}, Status: []string{"added"}},
+					},
+					{
+						Label: "ModifiesOnlyDocsAndCode",
+						Files: &FilesConfig{
+							All:    []string{"glob:*.md", "glob:*.go"},
+							None:   []string{"glob:/sub/"},
+							Status: []string{"modified"},
+						},
+					},
+					{
+						Label: "ModifiesDocsOutsideSub",
+						Files: &FilesConfig{
+							Any:    []string{"glob:*.md"},
+							None:   []string{"glob:sub/"},
+							Status: []string{"modified"},
+						},
+					},
+				},
+			},
+			initialLabels:  []string{"DocsOnly", "RenamesFiles"},
+			expectedLabels: []string{"NoVendor", "DocsAndCode", "AddsFiles", "RemovesConfig"},
+		},
+		{
+			event:    "pull_request",
+			payloads: []string{"diff_pr"},
+			name:     "Add labels based on the code owners of the changed files",
+			config: LabelerConfigV1{
+				Version: 1,
+				Labels: []LabelMatcher{
+					{Label: "Docs", CodeOwners: &CodeOwnersConfig{Owners: []string{"@org/docs"}}},
+					{Label: "SubTeam", CodeOwners: &CodeOwnersConfig{Owners: []string{"ORG/Sub-Team"}}},
+					{Label: "Payments", CodeOwners: &CodeOwnersConfig{Owners: []string{"@org/payments"}}},
+					{Label: "Owned", CodeOwners: &CodeOwnersConfig{}},
+				},
+			},
+			initialLabels:  []string{"Payments"},
+			expectedLabels: []string{"Docs", "SubTeam", "Owned"},
+		},
+		{
+			event:    "pull_request",
+			payloads: []string{"diff_pr"},
+			name:     "Generate labels from the code owners of the changed files",
+			config: LabelerConfigV1{
+				Version:       1,
+				ManagedLabels: []string{"team/*"},
+				Labels: []LabelMatcher{
+					{
+						CodeOwners: &CodeOwnersConfig{
+							Owners:      []string{"@org/docs", "@org/sub-team", "@org/payments"},
+							LabelPrefix: "team/",
+						},
+					},
+				},
+			},
+			initialLabels:  []string{"team/payments", "team/docs"},
+			expectedLabels: []string{"team/docs", "team/sub-team"},
+		},
+		{
+			event:    "pull_request",
+			payloads: []string{"create_pr"},
+			name:     "First matched label wins in a group with several matchers per label",
+			config: LabelerConfigV1{
+				Version: 1,
+				Groups: []LabelGroup{
+					{Name: "kind", Labels: []string{"X", "Y"}, Strategy: "first"},
+				},
+				Labels: []LabelMatcher{
+					{Label: "X", Title: "^Nope"},
+					{Label: "Y", Title: "test$"},
+					{Label: "X", Title: "^WIP"},
+				},
+			},
+			initialLabels:  []string{"X"},
+			expectedLabels: []string{"Y"},
+		},
+		{
+			event:    "issues",
+			payloads: []string{"issue_open"},
+			name:     "Managed labels are kept when their generators can't be evaluated",
+			config: LabelerConfigV1{
+				Version:       1,
+				Issues:        true,
+				ManagedLabels: []string{"team/*", "size/*"},
+				Labels: []LabelMatcher{
+					{CodeOwners: &CodeOwnersConfig{LabelPrefix: "team/"}},
+					{Label: "Testy", Title: "^Testy"},
+				},
+			},
+			initialLabels:  []string{"team/payments", "size/S"},
+			expectedLabels: []string{"team/payments", "size/S", "Testy"},
+		},
+		{
+			event:    "pull_request",
+			payloads: []string{"create_pr"},
+			name:     "Groups remove excluded labels in append-only mode",
+			config: LabelerConfigV1{
+				Version:    1,
+				AppendOnly: true,
+				Groups: []LabelGroup{
+					{Name: "kind", Labels: []string{"A", "B", "C"}},
+				},
+				Labels: []LabelMatcher{
+					{Label: "A", Title: "^Nope"},
+					{Label: "B", Title: "test$"},
+					{Label: "C", Title: "^Nope"},
+					{Label: "D", Title: "^Nope"},
+				},
+			},
+			initialLabels:  []string{"A", "D"},
+			expectedLabels: []string{"B", "D"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, file := range tc.payloads {
+				payload, err := loadPayload(file)
+				if err != nil {
+					fmt.Printf("Test `%s`: failed to load %s\n", tc.name, file)
+					t.Fatal(err)
+				}
+				fmt.Printf("Loaded payload: %s\n", string(payload))
+
+				fmt.Printf("--> TEST: %s \n", tc.name)
+				l := NewTestLabeler(t, tc)
+				err = l.HandleEvent(tc.event, &payload)
+				if err != nil {
+					fmt.Printf("Test failed: %s\n", tc.name)
+					t.Fatal(err)
+				}
+				assertLabels(t, l, tc.expectedLabels)
+			}
+		})
+	}
+}
+
+// NewTestLabeler returns a labeler that keeps the labels of the target in
+// memory, starting with tc.initialLabels. GetCurrentLabels returns them
+// as they are after the changes made by the labeler.
+func NewTestLabeler(t *testing.T, tc TestCase) Labeler {
+	labels := append([]string{}, tc.initialLabels...)
+	return Labeler{
+		FetchRepoConfig: func() (*LabelerConfigV1, error) {
+			return &tc.config, nil
+		},
+		GetCurrentLabels: func(target *Target) ([]string, error) {
+			return append([]string{}, labels...), nil
+		},
+		AddLabels: func(target *Target, add []string) error {
+			for _, label := range add {
+				if contains(labels, label) {
+					return fmt.Errorf("%s: label %s is already set", tc.name, label)
+				}
+				labels = append(labels, label)
+			}
+			return nil
+		},
+		RemoveLabels: func(target *Target, remove []string) error {
+			for _, label := range remove {
+				if !contains(labels, label) {
+					return fmt.Errorf("%s: label %s is not set", tc.name, label)
+				}
+				labels = without(labels, label)
+			}
+			return nil
+		},
+		GitHubFacade: &GitHubFacade{
+			GetRawDiff: func(owner, repo string, prNumber int) (string, error) {
+				file, err := os.Open("../test_data/diff_response")
+				if err != nil {
+					return "", err
+				}
+
+				data, err := ioutil.ReadAll(file)
+				return string(data), nil
+			},
+			// Will return the PR in the create_pr payload
+			GetPR: func(owner, repo string, prNumber int) (*gh.PullRequest, error) {
+				payload, err := loadPayload("create_pr")
+				if err != nil {
+					return nil, err
+				}
+				var event gh.PullRequestEvent
+				if err = json.Unmarshal(payload, &event); err != nil {
+					return nil, err
+				}
+				if owner != "srvaroa" || repo != "jsonrouter" || prNumber != event.GetNumber() {
+					return nil, fmt.Errorf("unexpected PR %s/%s#%d", owner, repo, prNumber)
+				}
+				return event.PullRequest, nil
+			},
+			ListReviews: func(owner, repo string, prNumber int) iter.Seq2[*gh.PullRequestReview, error] {
+				return listResponse[*gh.PullRequestReview]("list_reviews")
+			},
+			ListRequestedReviewers: func(owner, repo string, prNumber int) (*gh.Reviewers, error) {
+				var reviewers gh.Reviewers
+				err := loadResponse("requested_reviewers", &reviewers)
+				return &reviewers, err
+			},
+			// Will return the issues in the linked_issues response
+			GetIssue: func(owner, repo string, issueNo int) (*gh.Issue, error) {
+				var issues map[string]*gh.Issue
+				if err := loadResponse("linked_issues", &issues); err != nil {
+					return nil, err
+				}
+				ref := fmt.Sprintf("%s/%s#%d", owner, repo, issueNo)
+				if ref == "private-org/secret#3" {
+					// an issue that the token can't read
+					req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/repos/private-org/secret/issues/3", nil)
+					return nil, &gh.ErrorResponse{
+						Response: &http.Response{StatusCode: http.StatusNotFound, Request: req},
+						Message:  "Not Found",
+					}
+				}
+				issue, ok := issues[ref]
+				if !ok {
+					return nil, fmt.Errorf("unexpected issue %s/%s#%d", owner, repo, issueNo)
+				}
+				return issue, nil
+			},
+			// Will return the codeowners response as .github/CODEOWNERS
+			GetFileContent: func(owner, repo, path, ref string) (string, error) {
+				if path != ".github/CODEOWNERS" {
+					return "", ErrFileNotFound
+				}
+				content, err := os.ReadFile("../test_data/codeowners_response")
+				return string(content), err
+			},
+			ListCommits: func(owner, repo string, prNumber int) iter.Seq2[*gh.RepositoryCommit, error] {
+				return listResponse[*gh.RepositoryCommit]("list_commits")
+			},
+			ListStatuses: func(owner, repo, ref string) iter.Seq2[*gh.RepoStatus, error] {
+				return listResponse[*gh.RepoStatus]("list_statuses")
+			},
+			ListCheckRuns: func(owner, repo, ref string) iter.Seq2[*gh.CheckRun, error] {
+				return listResponse[*gh.CheckRun]("list_check_runs")
+			},
+			ListPRsForCommit: func(owner, repo, sha string) iter.Seq2[*gh.PullRequest, error] {
+				return listResponse[*gh.PullRequest]("list_prs_for_commit")
+			},
+			// Will return true whenever team contains the given user name
+			IsUserMemberOfTeam: func(org, user, team string) (bool, error) {
+				return strings.Contains(team, user), nil
+			},
+		},
+	}
+}
+
+func assertLabels(t *testing.T, l Labeler, expected []string) {
+	t.Helper()
+	labels, _ := l.GetCurrentLabels(nil)
+	sort.Strings(labels)
+	expected = append([]string{}, expected...)
+	sort.Strings(expected)
+	if !reflect.DeepEqual(expected, labels) {
+		t.Fatalf("Expecting labels %+v, got %+v", expected, labels)
+	}
+}
+
+func without(labels []string, label string) []string {
+	result := []string{}
+	for _, l := range labels {
+		if l != label {
+			result = append(result, l)
+		}
+	}
+	return result
+}
+
+func TestLabelsAreOnlyModifiedWhenNeeded(t *testing.T) {
+	payload, err := loadPayload("create_pr")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := NewTestLabeler(t, TestCase{
+		config: LabelerConfigV1{
+			Version: 1,
+			Labels: []LabelMatcher{
+				{Label: "WIP", Title: "^WIP:.*"},
+				{Label: "Stale", Title: "^Stale:.*"},
+			},
+		},
+		initialLabels: []string{"WIP", "Untouched"},
+	})
+	l.AddLabels = func(target *Target, labels []string) error {
+		t.Fatalf("Expect no labels to be added, got %+v", labels)
+		return nil
+	}
+	l.RemoveLabels = func(target *Target, labels []string) error {
+		t.Fatalf("Expect no labels to be removed, got %+v", labels)
+		return nil
+	}
+
+	err = l.HandleEvent("pull_request", &payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	payload, err := loadPayload("create_pr")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var plans []*LabelPlan
+	l := NewTestLabeler(t, TestCase{
+		config: LabelerConfigV1{
+			Version: 1,
+			Labels: []LabelMatcher{
+				{Label: "WIP", Title: "^WIP:.*"},
+				{Label: "Stale", Title: "^Stale:.*"},
+			},
+		},
+		initialLabels: []string{"Stale", "Untouched"},
+	})
+	l.DryRun = true
+	l.AddLabels = func(target *Target, labels []string) error {
+		t.Fatalf("Labels must not be added in dry run mode, got %+v", labels)
+		return nil
+	}
+	l.RemoveLabels = func(target *Target, labels []string) error {
+		t.Fatalf("Labels must not be removed in dry run mode, got %+v", labels)
+		return nil
+	}
+	l.ReportPlan = func(plan *LabelPlan) {
+		plans = append(plans, plan)
+	}
+
+	err = l.HandleEvent("pull_request", &payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := LabelPlan{
+		Owner:   "srvaroa",
+		Repo:    "jsonrouter",
+		IssueNo: 2,
+		Add:     []string{"WIP"},
+		Remove:  []string{"Stale"},
+		Matchers: []MatcherResult{
+			{
+				Label:     "WIP",
+				Evaluated: true,
+				Matched:   true,
+				Conditions: []ConditionResult{
+					{
+						Condition: "Title matches regex",
+						Status:    ConditionEvaluated,
+						Inputs:    []string{"Matching `^WIP:.*` against: `WIP: this is a test`"},
+						Matched:   true,
+					},
+				},
+			},
+			{
+				Label:     "Stale",
+				Evaluated: true,
+				Matched:   false,
+				Conditions: []ConditionResult{
+					{
+						Condition: "Title matches regex",
+						Status:    ConditionEvaluated,
+						Inputs:    []string{"Matching `^Stale:.*` against: `WIP: this is a test`"},
+						Matched:   false,
+					},
+				},
+			},
+		},
+	}
+	if len(plans) != 1 || !reflect.DeepEqual(expect, *plans[0]) {
+		t.Fatalf("Expect plan %+v, got %+v", expect, plans)
+	}
+}
+
+func TestWrapPrAsTarget(t *testing.T) {
+	payload, err := loadPayload("assigned_pr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var event gh.PullRequestEvent
+	if err = json.Unmarshal(payload, &event); err != nil {
+		t.Fatal(err)
+	}
+
+	target := wrapPrAsTarget(event.PullRequest)
+	if target.Milestone != "v2.0" {
+		t.Errorf("Expected milestone v2.0, got %s", target.Milestone)
+	}
+	if expect := []string{"alice", "bob"}; !reflect.DeepEqual(expect, target.Assignees) {
+		t.Errorf("Expected assignees %v, got %v", expect, target.Assignees)
+	}
+}
+
+func TestCommitAuthors(t *testing.T) {
+	var commits []*gh.RepositoryCommit
+	if err := loadResponse("list_commits", &commits); err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{"alice@example.com", "carol@example.com"}
+	if got := commitAuthors(commits); !reflect.DeepEqual(expect, got) {
+		t.Errorf("Expected authors %v, got %v", expect, got)
+	}
+	if got := commitAuthors(commits[:2]); !reflect.DeepEqual(expect[:1], got) {
+		t.Errorf("Expected authors %v, got %v", expect[:1], got)
 	}
 }
-
-type FakeHttpClient struct {
-}
-
-func (f *FakeHttpClient) Do(req *http.Request) (*http.Response, error) {
-	file, err := os.Open("../test_data/diff_response")
-	if err != nil {
-		return nil, err
-	}
-
-	data, err := ioutil.ReadAll(file)
-	if err != nil {
-		return nil, err
-	}
-
-	diffReader := strings.NewReader(string(data))
-	diffReadCloser := io.NopCloser(diffReader)
-
-	response := http.Response{
-		StatusCode: http.StatusOK,
-		Body:       diffReadCloser,
-	}
-	return &response, nil
-}
@@ -0,0 +1,82 @@
+package labeler
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// regexLabelPrefix marks a pattern in `managed-labels` as a regex
+const regexLabelPrefix = "regex:"
+
+// labelPattern matches the names of labels owned by the labeler. It is
+// either an exact name, a glob where `*` matches any characters but `/`
+// (e.g. `size/*`), or a regex prefixed with `regex:` that must match the
+// whole name.
+type labelPattern struct {
+	pattern string
+	regex   *regexp.Regexp
+}
+
+func newLabelPattern(pattern string) (labelPattern, error) {
+	if strings.HasPrefix(pattern, regexLabelPrefix) {
+		expr := strings.TrimPrefix(pattern, regexLabelPrefix)
+		regex, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return labelPattern{}, err
+		}
+		return labelPattern{pattern: pattern, regex: regex}, nil
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return labelPattern{}, err
+	}
+	return labelPattern{pattern: pattern}, nil
+}
+
+func (p labelPattern) matches(label string) bool {
+	if p.regex != nil {
+		return p.regex.MatchString(label)
+	}
+	matched, _ := path.Match(p.pattern, label)
+	return matched
+}
+
+// managedLabels returns the patterns of the labels owned by the labeler
+// as declared in `managed-labels`
+func managedLabels(config *LabelerConfigV1) ([]labelPattern, error) {
+	patterns := []labelPattern{}
+	for _, pattern := range config.ManagedLabels {
+		p, err := newLabelPattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern `%s` in managed-labels: %v", pattern, err)
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+// isManagedLabel tells whether the label is owned by the labeler
+func isManagedLabel(patterns []labelPattern, label string) bool {
+	for _, p := range patterns {
+		if p.matches(label) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasMatcher tells whether any matcher in the config sets the label
+func hasMatcher(config *LabelerConfigV1, label string) bool {
+	for _, matcher := range config.Labels {
+		if matcher.Label == label {
+			return true
+		}
+	}
+	return false
+}
+
+func validateLabelPattern(value string) error {
+	_, err := newLabelPattern(value)
+	return err
+}
@@ -0,0 +1,174 @@
+package labeler
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// globPathPrefix marks a file pattern as a glob, otherwise it is a regex
+const globPathPrefix = "glob:"
+
+// pathPattern matches file paths in the repository, either with a regex
+// or a glob following the syntax of CODEOWNERS and .gitignore files:
+//
+//   - `*` matches any characters but `/`, `?` a single one, and `**`
+//     matches across directories (`**/logs`, `docs/**`, `a/**/b`).
+//   - Patterns with a `/` at the start or in the middle are anchored to
+//     the root of the repository, the rest match at any depth.
+//   - A pattern that matches a directory matches all the files in it,
+//     and a trailing `/` only matches directories. The exception is a
+//     last segment `*` (e.g. `docs/*`), that only matches direct
+//     children.
+//   - A leading `!` negates the pattern.
+type pathPattern struct {
+	pattern string
+	regex   *regexp.Regexp
+	negated bool
+}
+
+// pathPatterns are matched in order, and the last one that matches a
+// path decides the result, so that negated patterns can exclude paths
+// matched by earlier ones.
+type pathPatterns []pathPattern
+
+func newPathPattern(pattern string) (pathPattern, error) {
+	if strings.HasPrefix(pattern, globPathPrefix) {
+		return newGlobPattern(strings.TrimPrefix(pattern, globPathPrefix))
+	}
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return pathPattern{}, err
+	}
+	return pathPattern{pattern: pattern, regex: regex}, nil
+}
+
+func newGlobPattern(glob string) (pathPattern, error) {
+	p := pathPattern{pattern: glob}
+	if strings.HasPrefix(glob, "!") {
+		p.negated = true
+		glob = glob[1:]
+	}
+
+	dirOnly := strings.HasSuffix(glob, "/")
+	glob = strings.TrimSuffix(glob, "/")
+	anchored := strings.Contains(glob, "/")
+	glob = strings.TrimPrefix(glob, "/")
+	if glob == "" {
+		return pathPattern{}, fmt.Errorf("empty glob")
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored && !strings.HasPrefix(glob, "**") {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					// `**/` matches zero or more directories
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				return pathPattern{}, fmt.Errorf("unclosed `[` in glob")
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			if c == '\\' && i+1 < len(glob) {
+				i++
+			}
+			// copy whole runes so that non-ASCII paths are kept as is
+			_, size := utf8.DecodeRuneInString(glob[i:])
+			b.WriteString(regexp.QuoteMeta(glob[i : i+size]))
+			i += size - 1
+		}
+	}
+	switch {
+	case dirOnly:
+		b.WriteString("/.*")
+	case strings.HasSuffix(glob, "/*") || glob == "*":
+		// only direct children
+	default:
+		b.WriteString("(?:/.*)?")
+	}
+	b.WriteString("$")
+
+	regex, err := regexp.Compile(b.String())
+	if err != nil {
+		return pathPattern{}, err
+	}
+	p.regex = regex
+	return p, nil
+}
+
+func newPathPatterns(patterns []string) (pathPatterns, error) {
+	compiled := pathPatterns{}
+	for _, pattern := range patterns {
+		p, err := newPathPattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file pattern `%s` in configuration: %v", pattern, err)
+		}
+		compiled = append(compiled, p)
+	}
+	return compiled, nil
+}
+
+// matches tells whether the last pattern that matches the path is not
+// negated
+func (p pathPatterns) matches(path string) bool {
+	if path == "" {
+		return false
+	}
+	matched := false
+	for _, pattern := range p {
+		if pattern.regex.MatchString(path) {
+			matched = !pattern.negated
+		}
+	}
+	return matched
+}
+
+// matchesAny tells whether the patterns match any of the paths
+func (p pathPatterns) matchesAny(paths []string) (string, bool) {
+	for _, path := range paths {
+		if p.matches(path) {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// matchesAll tells whether the patterns match all of the paths, returning
+// the first one that doesn't match otherwise
+func (p pathPatterns) matchesAll(paths []string) (string, bool) {
+	for _, path := range paths {
+		if !p.matches(path) {
+			return path, false
+		}
+	}
+	return "", true
+}
+
+func validatePathPattern(value string) error {
+	_, err := newPathPattern(value)
+	return err
+}
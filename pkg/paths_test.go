@@ -0,0 +1,84 @@
+package labeler
+
+import (
+	"testing"
+)
+
+func TestPathPatterns(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		path     string
+		expected bool
+	}{
+		// regexes are the default, and match anywhere in the path
+		{[]string{"\\.md$"}, "docs/README.md", true},
+		{[]string{"R.+.md"}, "docs/README.md", true},
+		{[]string{"\\.md$"}, "docs/README.mdx", false},
+		// unanchored globs match at any depth
+		{[]string{"glob:*.md"}, "README.md", true},
+		{[]string{"glob:*.md"}, "docs/README.md", true},
+		{[]string{"glob:*.md"}, "READMEmd", false},
+		{[]string{"glob:README.md"}, "docs/README.md", true},
+		{[]string{"glob:README.md"}, "docs/xREADME.md", false},
+		{[]string{"glob:te?t.go"}, "pkg/test.go", true},
+		{[]string{"glob:te?t.go"}, "pkg/tet.go", false},
+		{[]string{"glob:[Mm]akefile"}, "makefile", true},
+		{[]string{"glob:[!M]akefile"}, "Makefile", false},
+		// patterns with a slash are anchored to the root
+		{[]string{"glob:/docs"}, "docs/index.md", true},
+		{[]string{"glob:/docs"}, "api/docs/index.md", false},
+		{[]string{"glob:docs/*.md"}, "docs/index.md", true},
+		{[]string{"glob:docs/*.md"}, "api/docs/index.md", false},
+		// directories match all the files in them
+		{[]string{"glob:apps/"}, "apps/main.go", true},
+		{[]string{"glob:apps/"}, "src/apps/web/main.go", true},
+		{[]string{"glob:apps/"}, "apps", false},
+		{[]string{"glob:/build/logs/"}, "build/logs/a/b.log", true},
+		{[]string{"glob:pkg"}, "pkg/labeler.go", true},
+		// but `*` as last segment only matches direct children
+		{[]string{"glob:docs/*"}, "docs/index.md", true},
+		{[]string{"glob:docs/*"}, "docs/api/index.md", false},
+		// `**` matches across directories
+		{[]string{"glob:docs/**"}, "docs/api/index.md", true},
+		{[]string{"glob:**/logs"}, "logs/a.log", true},
+		{[]string{"glob:**/logs"}, "deep/down/logs/a.log", true},
+		{[]string{"glob:a/**/b.go"}, "a/b.go", true},
+		{[]string{"glob:a/**/b.go"}, "a/x/y/b.go", true},
+		{[]string{"glob:a/**/b.go"}, "c/a/x/b.go", false},
+		{[]string{"glob:/**/*.go"}, "cmd/main.go", true},
+		// the last matching pattern wins, negations exclude paths
+		{[]string{"glob:*.go", "glob:!vendor/"}, "pkg/labeler.go", true},
+		{[]string{"glob:*.go", "glob:!vendor/"}, "vendor/x/y.go", false},
+		{[]string{"glob:!vendor/", "glob:*.go"}, "vendor/x/y.go", true},
+		{[]string{"\\.go$", "glob:!**/*_test.go"}, "pkg/labeler_test.go", false},
+		{[]string{"glob:!*.go"}, "main.go", false},
+		// escaped and literal characters
+		{[]string{"glob:\\*.go"}, "*.go", true},
+		{[]string{"glob:\\*.go"}, "main.go", false},
+		{[]string{"glob:a+b.txt"}, "a+b.txt", true},
+		{[]string{"glob:a.b"}, "axb", false},
+		// non-ASCII characters
+		{[]string{"glob:docs/é.md"}, "docs/é.md", true},
+		{[]string{"glob:**/日本語/*"}, "i18n/日本語/README.md", true},
+		{[]string{"glob:?.md"}, "é.md", true},
+		{[]string{"glob:\\ñ.md"}, "ñ.md", true},
+	}
+
+	for _, test := range tests {
+		patterns, err := newPathPatterns(test.patterns)
+		if err != nil {
+			t.Fatalf("failed to parse %v: %v", test.patterns, err)
+		}
+		if result := patterns.matches(test.path); result != test.expected {
+			t.Errorf("expected %v to match %s: %t, got %t", test.patterns, test.path, test.expected, result)
+		}
+	}
+}
+
+func TestInvalidPathPatterns(t *testing.T) {
+	for _, pattern := range []string{"glob:", "glob:/", "glob:[abc", "(regex"} {
+		if _, err := newPathPattern(pattern); err == nil {
+			t.Errorf("expected an error parsing %s", pattern)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package labeler
+
+import (
+	"log"
+	"sync"
+)
+
+// maxConcurrency caps the number of targets processed in parallel, as
+// GitHub applies secondary rate limits to clients that make too many
+// concurrent requests.
+const maxConcurrency = 10
+
+// TargetResult is the outcome of processing one PR or issue
+type TargetResult struct {
+	Owner   string
+	Repo    string
+	IssueNo int
+	// Plan holds the label changes computed for the target, nil if
+	// they could not be computed
+	Plan *LabelPlan
+	Err  error
+}
+
+// processTargets executes the labeler on all the targets using a pool of
+// l.Concurrency workers. Plans are reported once all targets have been
+// processed, in the same order as the targets, followed by a summary of
+// the errors found.
+func (l *Labeler) processTargets(kind string, targets []*Target) []TargetResult {
+	workers := l.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > maxConcurrency {
+		log.Printf("Concurrency %d is above the maximum, using %d", workers, maxConcurrency)
+		workers = maxConcurrency
+	}
+	if workers > len(targets) {
+		workers = len(targets)
+	}
+	log.Printf("Processing %d %ss with %d workers", len(targets), kind, workers)
+
+	results := make([]TargetResult, len(targets))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				target := targets[i]
+				result := &results[i]
+				result.Owner = target.Owner
+				result.Repo = target.RepoName
+				result.IssueNo = target.IssueNo
+				result.Err = l.executeOn(target, func(plan *LabelPlan) {
+					result.Plan = plan
+				})
+			}
+		}()
+	}
+	for i := range targets {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	failed := 0
+	for _, result := range results {
+		if result.Plan != nil {
+			l.reportPlan(result.Plan)
+		}
+		if result.Err != nil {
+			failed++
+			log.Printf("Unable to execute action on %s #%d: %+v", kind, result.IssueNo, result.Err)
+		}
+	}
+	log.Printf("Processed %d %ss, %d failed", len(results), kind, failed)
+	return results
+}
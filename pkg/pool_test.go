@@ -0,0 +1,91 @@
+package labeler
+
+import (
+	"fmt"
+	"iter"
+	"reflect"
+	"sync"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+)
+
+func TestProcessAllPRsConcurrently(t *testing.T) {
+	repo := &gh.PullRequestBranch{Repo: &gh.Repository{Name: gh.String("repo"), Owner: &gh.User{Login: gh.String("y")}}}
+	fakePRs := []*gh.PullRequest{}
+	for i := 1; i <= 20; i++ {
+		fakePRs = append(fakePRs, &gh.PullRequest{
+			Number: gh.Int(i),
+			State:  gh.String("open"),
+			Title:  gh.String(fmt.Sprintf("PR %d", i)),
+			User:   &gh.User{Login: gh.String("user")},
+			Base:   repo,
+		})
+	}
+
+	var mu sync.Mutex
+	updated := map[int][]string{}
+	reported := []int{}
+	l := &Labeler{
+		FetchRepoConfig: func() (*LabelerConfigV1, error) {
+			return &LabelerConfigV1{Version: 1, Labels: []LabelMatcher{
+				{Label: "Odd", Title: "[13579]$"},
+			}}, nil
+		},
+		GetCurrentLabels: func(target *Target) ([]string, error) {
+			if target.IssueNo%5 == 0 {
+				return nil, fmt.Errorf("boom")
+			}
+			return nil, nil
+		},
+		AddLabels: func(target *Target, labels []string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			updated[target.IssueNo] = labels
+			return nil
+		},
+		ReportPlan: func(plan *LabelPlan) {
+			reported = append(reported, plan.IssueNo)
+		},
+		Concurrency: 4,
+		GitHubFacade: &GitHubFacade{
+			ListPRs: func(owner, repo string) iter.Seq2[*gh.PullRequest, error] { return listOf(fakePRs) },
+		},
+	}
+
+	results, err := l.ProcessAllPRs("y", "repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != len(fakePRs) {
+		t.Fatalf("Expected %d results, got %d", len(fakePRs), len(results))
+	}
+	expectedReported := []int{}
+	for i, result := range results {
+		prNo := i + 1
+		if result.IssueNo != prNo || result.Owner != "y" || result.Repo != "repo" {
+			t.Errorf("Expected result %d to be for y/repo#%d, got %+v", i, prNo, result)
+		}
+		if prNo%5 == 0 {
+			if result.Err == nil || result.Plan != nil {
+				t.Errorf("Expected an error and no plan for #%d, got %+v", prNo, result)
+			}
+			continue
+		}
+		expectedReported = append(expectedReported, prNo)
+		var expectedLabels []string
+		if prNo%2 == 1 {
+			expectedLabels = []string{"Odd"}
+		}
+		if result.Err != nil {
+			t.Errorf("Unexpected error for #%d: %v", prNo, result.Err)
+		}
+		if !reflect.DeepEqual(expectedLabels, updated[prNo]) {
+			t.Errorf("Expected labels %v for #%d, got %v", expectedLabels, prNo, updated[prNo])
+		}
+	}
+	if !reflect.DeepEqual(expectedReported, reported) {
+		t.Errorf("Expected plans to be reported in order %v, got %v", expectedReported, reported)
+	}
+}
@@ -0,0 +1,119 @@
+package labeler
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/go-yaml/yaml"
+)
+
+var (
+	registryMu           sync.RWMutex
+	registeredConditions []Condition
+)
+
+// RegisterCondition makes a condition available in label matchers under
+// the given config key, so that packages embedding the labeler can extend
+// it with their own conditions.  The condition reads its configuration
+// from the matcher with LabelMatcher.DecodeCondition, and is evaluated
+// after all the built-in conditions, in order of registration.
+//
+// RegisterCondition is meant to be called from init functions, and panics
+// if the key is empty or already in use.
+func RegisterCondition(name string, c Condition) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if name == "" {
+		panic("labeler: RegisterCondition with an empty name")
+	}
+	if c.Evaluate == nil {
+		panic("labeler: RegisterCondition with a nil Evaluate for " + name)
+	}
+	if _, builtin := matcherFields[name]; builtin {
+		panic("labeler: RegisterCondition called for built-in key " + name)
+	}
+	if isConditionRegistered(name) {
+		panic("labeler: RegisterCondition called twice for " + name)
+	}
+
+	if c.GetName == nil {
+		c.GetName = func() string { return name }
+	}
+	if c.CanEvaluate == nil {
+		c.CanEvaluate = func(target *Target) bool { return true }
+	}
+	c.Keys = []string{name}
+	registeredConditions = append(registeredConditions, c)
+}
+
+// isConditionRegistered tells whether a condition was registered for the
+// given config key. The caller must hold registryMu.
+func isConditionRegistered(name string) bool {
+	for _, c := range registeredConditions {
+		if c.Keys[0] == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isRegisteredConditionKey(name string) bool {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return isConditionRegistered(name)
+}
+
+// getConditions returns the built-in conditions followed by those
+// registered with RegisterCondition
+func getConditions() []Condition {
+	conditions := []Condition{
+		AgeCondition(),
+		AssigneesCondition(),
+		AuthorCondition(),
+		AuthorCanMergeCondition(),
+		AuthorInTeamCondition(),
+		BaseBranchCondition(),
+		BodyCondition(),
+		BranchCondition(),
+		ChecksCondition(),
+		CodeOwnersCondition(),
+		CommitsCondition(),
+		DiffCondition(),
+		FilesCondition(),
+		HasAssigneeCondition(),
+		LabelsCondition(),
+		LinkedIssuesCondition(),
+		LastModifiedCondition(),
+		IsDraftCondition(),
+		IsMergeableCondition(),
+		MilestoneCondition(),
+		ReviewsCondition(),
+		SizeCondition(),
+		TitleCondition(),
+		TypeCondition(),
+	}
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return append(conditions, registeredConditions...)
+}
+
+// DecodeCondition decodes the configuration set in the matcher for a
+// condition registered with RegisterCondition into out, which works like
+// the target of yaml.Unmarshal.  It returns false if the matcher doesn't
+// configure the condition.
+func (m LabelMatcher) DecodeCondition(name string, out interface{}) (bool, error) {
+	value, ok := m.Extensions[name]
+	if !ok {
+		return false, nil
+	}
+	raw, err := yaml.Marshal(value)
+	if err != nil {
+		return true, fmt.Errorf("failed to read `%s` in configuration: %v", name, err)
+	}
+	if err = yaml.Unmarshal(raw, out); err != nil {
+		return true, fmt.Errorf("failed to parse `%s` in configuration: %v", name, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,84 @@
+package labeler
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-yaml/yaml"
+)
+
+type titleLengthConfig struct {
+	AtLeast int `yaml:"at-least"`
+}
+
+func init() {
+	RegisterCondition("title-length", Condition{
+		Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
+			var cfg titleLengthConfig
+			ok, err := matcher.DecodeCondition("title-length", &cfg)
+			if err != nil {
+				return false, err
+			}
+			if !ok {
+				return false, fmt.Errorf("title-length is not set in config")
+			}
+			target.Observe("Title has %d characters", len(target.Title))
+			return len(target.Title) >= cfg.AtLeast, nil
+		},
+	})
+}
+
+func TestRegisteredCondition(t *testing.T) {
+	configRaw := []byte(`
+version: 1
+labels:
+- label: "long-title"
+  title-length:
+    at-least: 10
+- label: "very-long-title"
+  title: "^WIP"
+  title-length:
+    at-least: 100
+`)
+
+	if problems := ValidateConfig(configRaw); len(problems) > 0 {
+		t.Fatalf("Expect registered conditions to be valid, got %+v", problems)
+	}
+
+	var config LabelerConfigV1
+	if err := yaml.Unmarshal(configRaw, &config); err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := loadPayload("create_pr")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := NewTestLabeler(t, TestCase{
+		name:           "Registered condition",
+		config:         config,
+		initialLabels:  []string{"very-long-title"},
+		expectedLabels: []string{"long-title"},
+	})
+	if err = l.HandleEvent("pull_request", &payload); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegisterConditionTwice(t *testing.T) {
+	for _, name := range []string{"title-length", "title", ""} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expect RegisterCondition(%q) to panic", name)
+				}
+			}()
+			RegisterCondition(name, Condition{
+				Evaluate: func(target *TargetContext, matcher LabelMatcher) (bool, error) {
+					return true, nil
+				},
+			})
+		}()
+	}
+}
@@ -0,0 +1,168 @@
+package labeler
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	gh "github.com/google/go-github/v50/github"
+)
+
+// defaultLabelColor is used for labels created without a color in the
+// config, the same that GitHub uses
+const defaultLabelColor = "ededed"
+
+var labelColorRegex = regexp.MustCompile("^#?[0-9a-fA-F]{6}$")
+
+// RepoLabel is a label as defined in a repository
+type RepoLabel struct {
+	Name        string `json:"name"`
+	Color       string `json:"color,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// LabelSyncReport describes the changes made to the labels of a
+// repository to match the config
+type LabelSyncReport struct {
+	Created []RepoLabel `json:"created"`
+	Updated []RepoLabel `json:"updated"`
+	// Unreferenced are labels of the repository that the config doesn't
+	// mention in matchers, groups or managed labels
+	Unreferenced []string `json:"unreferenced"`
+}
+
+// SyncLabels creates the labels of the config that are missing in the
+// repository, and updates the color and description of existing ones
+// when they differ from the config.  Labels are only compared, never
+// modified, in dry run mode.
+func (l *Labeler) SyncLabels(owner, repo string) (*LabelSyncReport, error) {
+	config, err := l.FetchRepoConfig()
+	if err != nil {
+		return nil, err
+	}
+	managed, err := managedLabels(config)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string]*gh.Label{}
+	names := []string{}
+	for label, err := range l.GitHubFacade.ListRepoLabels(owner, repo) {
+		if err != nil {
+			return nil, err
+		}
+		existing[strings.ToLower(label.GetName())] = label
+		names = append(names, label.GetName())
+	}
+
+	report := &LabelSyncReport{
+		Created:      []RepoLabel{},
+		Updated:      []RepoLabel{},
+		Unreferenced: []string{},
+	}
+	for _, want := range configuredLabels(config) {
+		current, ok := existing[strings.ToLower(want.Name)]
+		if !ok {
+			if want.Color == "" {
+				want.Color = defaultLabelColor
+			}
+			log.Printf("Creating label %s", want.Name)
+			report.Created = append(report.Created, want)
+			if !l.DryRun {
+				err = l.GitHubFacade.CreateLabel(owner, repo, &gh.Label{
+					Name:        gh.String(want.Name),
+					Color:       gh.String(want.Color),
+					Description: gh.String(want.Description),
+				})
+			}
+		} else if needsUpdate(current, want) {
+			log.Printf("Updating label %s", want.Name)
+			report.Updated = append(report.Updated, want)
+			if !l.DryRun {
+				update := &gh.Label{Name: gh.String(current.GetName())}
+				if want.Color != "" {
+					update.Color = gh.String(want.Color)
+				}
+				if want.Description != "" {
+					update.Description = gh.String(want.Description)
+				}
+				err = l.GitHubFacade.EditLabel(owner, repo, current.GetName(), update)
+			}
+		}
+		if err != nil {
+			return report, fmt.Errorf("unable to sync label %s: %v", want.Name, err)
+		}
+	}
+
+	for _, name := range names {
+		if !isReferencedLabel(config, managed, name) {
+			report.Unreferenced = append(report.Unreferenced, name)
+		}
+	}
+	sort.Strings(report.Unreferenced)
+	return report, nil
+}
+
+// configuredLabels returns the labels set by matchers in the config, in
+// order and with the first color and description declared for each one
+func configuredLabels(config *LabelerConfigV1) []RepoLabel {
+	labels := []RepoLabel{}
+	index := map[string]int{}
+	for _, matcher := range config.Labels {
+		if matcher.Label == "" {
+			continue
+		}
+		i, ok := index[matcher.Label]
+		if !ok {
+			i = len(labels)
+			index[matcher.Label] = i
+			labels = append(labels, RepoLabel{Name: matcher.Label})
+		}
+		if labels[i].Color == "" {
+			labels[i].Color = strings.TrimPrefix(matcher.Color, "#")
+		}
+		if labels[i].Description == "" {
+			labels[i].Description = matcher.Description
+		}
+	}
+	return labels
+}
+
+// needsUpdate tells whether the color or description declared in the
+// config differ from those of the repository label
+func needsUpdate(current *gh.Label, want RepoLabel) bool {
+	if want.Color != "" && !strings.EqualFold(want.Color, current.GetColor()) {
+		return true
+	}
+	return want.Description != "" && want.Description != current.GetDescription()
+}
+
+// isReferencedLabel tells whether the config mentions the label, which
+// GitHub compares ignoring case
+func isReferencedLabel(config *LabelerConfigV1, managed []labelPattern, name string) bool {
+	if isManagedLabel(managed, name) {
+		return true
+	}
+	referenced := []string{}
+	for _, matcher := range config.Labels {
+		referenced = append(referenced, matcher.Label)
+	}
+	for _, group := range config.Groups {
+		referenced = append(referenced, group.Labels...)
+	}
+	for _, label := range referenced {
+		if strings.EqualFold(label, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateColor(value string) error {
+	if !labelColorRegex.MatchString(value) {
+		return fmt.Errorf("must be a hex color like `d73a4a`")
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package labeler
+
+import (
+	"iter"
+	"reflect"
+	"testing"
+
+	gh "github.com/google/go-github/v50/github"
+)
+
+func TestSyncLabels(t *testing.T) {
+	config := LabelerConfigV1{
+		Version:       1,
+		ManagedLabels: []string{"size/*"},
+		Groups: []LabelGroup{
+			{Name: "priority", Labels: []string{"P0", "P1"}},
+		},
+		Labels: []LabelMatcher{
+			{Label: "WIP", Title: "^WIP", Color: "#FBCA04", Description: "Work in progress"},
+			{Label: "WIP", Draft: "true", Color: "000000", Description: "Ignored"},
+			{Label: "docs", Files: &FilesConfig{Any: []string{"\\.md$"}}, Description: "Documentation"},
+			{Label: "bug", Title: "fix", Color: "d73a4a"},
+			{Label: "new", Title: "feat"},
+		},
+	}
+	repoLabels := []*gh.Label{
+		{Name: gh.String("wip"), Color: gh.String("fbca04"), Description: gh.String("Work in progress")},
+		{Name: gh.String("docs"), Color: gh.String("0075ca"), Description: gh.String("Docs")},
+		{Name: gh.String("bug"), Color: gh.String("d73a4a")},
+		{Name: gh.String("size/XL"), Color: gh.String("ededed")},
+		{Name: gh.String("P1"), Color: gh.String("ededed")},
+		{Name: gh.String("wontfix"), Color: gh.String("ffffff")},
+		{Name: gh.String("duplicate"), Color: gh.String("cfd3d7")},
+	}
+
+	for _, dryRun := range []bool{false, true} {
+		created := []*gh.Label{}
+		edited := map[string]*gh.Label{}
+		l := &Labeler{
+			FetchRepoConfig: func() (*LabelerConfigV1, error) { return &config, nil },
+			DryRun:          dryRun,
+			GitHubFacade: &GitHubFacade{
+				ListRepoLabels: func(owner, repo string) iter.Seq2[*gh.Label, error] {
+					return listOf(repoLabels)
+				},
+				CreateLabel: func(owner, repo string, label *gh.Label) error {
+					created = append(created, label)
+					return nil
+				},
+				EditLabel: func(owner, repo, name string, label *gh.Label) error {
+					edited[name] = label
+					return nil
+				},
+			},
+		}
+
+		report, err := l.SyncLabels("srvaroa", "labeler")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expect := &LabelSyncReport{
+			Created:      []RepoLabel{{Name: "new", Color: "ededed"}},
+			Updated:      []RepoLabel{{Name: "docs", Description: "Documentation"}},
+			Unreferenced: []string{"duplicate", "wontfix"},
+		}
+		if !reflect.DeepEqual(expect, report) {
+			t.Errorf("Expected report %+v, got %+v", expect, report)
+		}
+
+		if dryRun {
+			if len(created) > 0 || len(edited) > 0 {
+				t.Errorf("Expected no changes in dry run mode, got %+v and %+v", created, edited)
+			}
+			continue
+		}
+		expectCreated := []*gh.Label{{Name: gh.String("new"), Color: gh.String("ededed"), Description: gh.String("")}}
+		if !reflect.DeepEqual(expectCreated, created) {
+			t.Errorf("Expected created labels %+v, got %+v", expectCreated, created)
+		}
+		expectEdited := map[string]*gh.Label{"docs": {Name: gh.String("docs"), Description: gh.String("Documentation")}}
+		if !reflect.DeepEqual(expectEdited, edited) {
+			t.Errorf("Expected edited labels %+v, got %+v", expectEdited, edited)
+		}
+	}
+}
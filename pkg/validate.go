@@ -0,0 +1,352 @@
+package labeler
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigError describes a problem found in a configuration file, along
+// with its position in the file.
+type ConfigError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e ConfigError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// ValidateConfig strictly parses a raw configuration and returns all the
+// problems found in it: unknown fields, values of the wrong type and
+// values that conditions would reject at evaluation time (invalid
+// regexes, durations, etc.).  An empty result means the config is valid.
+func ValidateConfig(raw []byte) []ConfigError {
+	var root yaml.Node
+	if err := yaml.Unmarshal(raw, &root); err != nil {
+		return []ConfigError{yamlSyntaxError(err)}
+	}
+	if len(root.Content) == 0 {
+		return nil // empty file
+	}
+
+	doc := root.Content[0]
+	v := &configValidator{}
+	if doc.Kind != yaml.MappingNode {
+		v.addError(doc, "expected a mapping at the top level of the config")
+		return v.errors
+	}
+
+	version := findKey(doc, "version")
+	if version == nil {
+		if findKey(doc, "labels") != nil {
+			v.addError(doc, "missing `version: 1`, the config would be parsed with the legacy format")
+			return v.errors
+		}
+		// Legacy config, a map of label to matcher
+		v.walk(doc, reflect.TypeOf(LabelerConfigV0{}), "")
+		return v.errors
+	}
+	if version.Value != "1" {
+		v.addError(version, "unsupported version %q, expected 1", version.Value)
+		return v.errors
+	}
+	v.walk(doc, reflect.TypeOf(LabelerConfigV1{}), "")
+	return v.errors
+}
+
+// matcherFieldValidators check the values of matcher fields beyond what
+// their types allow.  They are keyed by the path of the field relative to
+// the matcher (or the top level of the config outside of matchers), and
+// apply to scalars and each item in lists.
+var matcherFieldValidators = map[string]func(value string) error{
+	"age":                                 validateDuration,
+	"age-range.at-least":                  validateDuration,
+	"age-range.at-most":                   validateDuration,
+	"author-can-merge":                    validateBool,
+	"base-branch":                         validateRegex,
+	"body":                                validateRegex,
+	"branch":                              validateRegex,
+	"checks.names":                        validateRegex,
+	"checks.state":                        validateCheckState,
+	"color":                               validateColor,
+	"commits.count.at-least":              validateInt,
+	"commits.count.at-most":               validateInt,
+	"commits.message":                     validateRegex,
+	"commits.multiple-authors":            validateBool,
+	"commits.unsigned":                    validateBool,
+	"diff.added-lines":                    validateRegex,
+	"diff.count.at-least":                 validateInt,
+	"diff.count.at-most":                  validateInt,
+	"diff.files":                          validatePathPattern,
+	"diff.removed-lines":                  validateRegex,
+	"draft":                               validateBool,
+	"files":                               validatePathPattern,
+	"files.all":                           validatePathPattern,
+	"files.any":                           validatePathPattern,
+	"files.none":                          validatePathPattern,
+	"files.status":                        validateFileStatus,
+	"groups.strategy":                     validateGroupStrategy,
+	"has-assignee":                        validateBool,
+	"labels.has-all":                      validateLabelPattern,
+	"labels.has-any":                      validateLabelPattern,
+	"labels.has-none":                     validateLabelPattern,
+	"last-modified.at-least":              validateDuration,
+	"last-modified.at-most":               validateDuration,
+	"linked-issues.copy-labels":           validateLabelPattern,
+	"linked-issues.labels":                validateRegex,
+	"linked-issues.milestone":             validateRegex,
+	"linked-issues.state":                 validateIssueState,
+	"managed-labels":                      validateLabelPattern,
+	"mergeable":                           validateBool,
+	"milestone":                           validateRegex,
+	"reviews.approvals.at-least":          validateInt,
+	"reviews.approvals.at-most":           validateInt,
+	"reviews.changes-requested":           validateBool,
+	"reviews.requested-reviewers-pending": validateBool,
+	"size-above":                          validateInt,
+	"size-below":                          validateInt,
+	"size.above":                          validateInt,
+	"size.below":                          validateInt,
+	"size.exclude-files":                  validatePathPattern,
+	"title":                               validateRegex,
+	"type":                                validateType,
+}
+
+// listShorthands are the config objects that also accept a list, which
+// is decoded into the given field and validated as the object itself
+var listShorthands = map[reflect.Type]string{
+	reflect.TypeOf(AssigneesConfig{}): "any",
+	reflect.TypeOf(FilesConfig{}):     "any",
+}
+
+// generatorSettings only apply to label generators, matchers without a
+// label, and are ignored elsewhere
+var generatorSettings = []struct{ condition, field string }{
+	{"codeowners", "label-prefix"},
+	{"linked-issues", "copy-labels"},
+}
+
+type configValidator struct {
+	errors []ConfigError
+}
+
+func (v *configValidator) addError(node *yaml.Node, format string, args ...interface{}) {
+	v.errors = append(v.errors, ConfigError{
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// walk verifies that the node can be decoded into a value of type t.
+// path tracks the position of the node relative to the closest matcher.
+func (v *configValidator) walk(node *yaml.Node, t reflect.Type, path string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Tag == "!!null" {
+		return
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(LabelMatcher{}) {
+		path = ""
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if key, ok := listShorthands[t]; ok {
+			if node.Kind == yaml.SequenceNode {
+				v.walk(node, yamlFields(t)[key].Type, path)
+				return
+			}
+			if node.Kind != yaml.MappingNode {
+				v.addError(node, "expected a list or a mapping%s", describePath(path))
+				return
+			}
+		}
+		if node.Kind != yaml.MappingNode {
+			v.addError(node, "expected a mapping%s", describePath(path))
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := fields[key.Value]
+			if !ok && t == reflect.TypeOf(LabelMatcher{}) && isRegisteredConditionKey(key.Value) {
+				continue // registered conditions accept any value
+			}
+			if !ok {
+				v.addError(key, "unknown field `%s`", key.Value)
+				continue
+			}
+			v.walk(value, field.Type, joinPath(path, key.Value))
+		}
+		if t == reflect.TypeOf(LabelMatcher{}) {
+			v.checkGeneratorSettings(node)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			v.addError(node, "expected a mapping%s", describePath(path))
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v.walk(node.Content[i+1], t.Elem(), path)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			v.addError(node, "expected a list%s", describePath(path))
+			return
+		}
+		for _, item := range node.Content {
+			v.walk(item, t.Elem(), path)
+		}
+	case reflect.Interface:
+		// Any value is accepted
+	default:
+		if node.Kind != yaml.ScalarNode {
+			v.addError(node, "expected a single value%s", describePath(path))
+			return
+		}
+		v.validateScalar(node, t, path)
+	}
+}
+
+func (v *configValidator) validateScalar(node *yaml.Node, t reflect.Type, path string) {
+	switch t.Kind() {
+	case reflect.Bool:
+		if !isYamlBool(node.Value) {
+			v.addError(node, "`%s` must be true or false, got %q", path, node.Value)
+			return
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if validateInt(node.Value) != nil {
+			v.addError(node, "`%s` must be a number, got %q", path, node.Value)
+			return
+		}
+	}
+	if validate, ok := matcherFieldValidators[path]; ok {
+		if err := validate(node.Value); err != nil {
+			v.addError(node, "invalid `%s`: %s", path, err)
+		}
+	}
+}
+
+// yamlFields returns the fields of a struct indexed by the key they are
+// decoded from, following the same rules as the yaml decoder.
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		name := tag[0]
+		if name == "-" || (len(tag) > 1 && tag[1] == "inline") {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// checkGeneratorSettings reports the settings of label generators used
+// in a matcher with a label
+func (v *configValidator) checkGeneratorSettings(matcher *yaml.Node) {
+	if label := findKey(matcher, "label"); label == nil || label.Value == "" {
+		return
+	}
+	for _, setting := range generatorSettings {
+		condition := findKey(matcher, setting.condition)
+		if condition == nil || condition.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(condition.Content); i += 2 {
+			if key := condition.Content[i]; key.Value == setting.field {
+				v.addError(key, "`%s.%s` is ignored in matchers with a `label`",
+					setting.condition, setting.field)
+			}
+		}
+	}
+}
+
+func findKey(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func describePath(path string) string {
+	if path == "" {
+		return ""
+	}
+	return fmt.Sprintf(" for `%s`", path)
+}
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+func yamlSyntaxError(err error) ConfigError {
+	configErr := ConfigError{Message: err.Error()}
+	if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+		configErr.Line, _ = strconv.Atoi(m[1])
+	}
+	return configErr
+}
+
+func validateRegex(value string) error {
+	_, err := regexp.Compile(value)
+	return err
+}
+
+func validateDuration(value string) error {
+	_, err := parseExtendedDuration(value)
+	return err
+}
+
+func validateInt(value string) error {
+	_, err := strconv.ParseInt(value, 0, 64)
+	return err
+}
+
+// validateBool accepts the values that conditions parse as booleans
+func validateBool(value string) error {
+	_, err := strconv.ParseBool(value)
+	return err
+}
+
+// isYamlBool tells whether the yaml decoder accepts value as a bool
+func isYamlBool(value string) bool {
+	switch strings.ToLower(value) {
+	case "y", "yes", "n", "no", "on", "off", "true", "false":
+		return true
+	}
+	return false
+}
+
+func validateType(value string) error {
+	if value != "pull_request" && value != "issue" {
+		return fmt.Errorf("must be `pull_request` or `issue`")
+	}
+	return nil
+}
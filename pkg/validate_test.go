@@ -0,0 +1,241 @@
+package labeler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		expect []ConfigError
+	}{
+		{
+			name: "Valid config",
+			config: `
+version: 1
+issues: true
+labels:
+- label: "WIP"
+  title: "^WIP:.*"
+  draft: True
+- label: "docs"
+  files: ["^docs/.*"]
+  any:
+  - age-range:
+      at-least: 2w
+  - size:
+      exclude-files: ["go.sum"]
+      above: 100
+`,
+		},
+		{
+			name: "Valid legacy config",
+			config: `
+WIP:
+  title: "^WIP:.*"
+S:
+  size-below: 10
+`,
+		},
+		{
+			name: "Reports all problems with their position",
+			config: `
+version: 1
+appendonly: true
+labels:
+- label: "WIP"
+  title: "^WIP(:.*"
+  negate: maybe
+- label: "old"
+  last-modified:
+    at-least: 2 weeks
+  type: "issues"
+- label: "docs"
+  files: "^docs/.*"
+  not:
+    draft: sure
+    authorz: ["me"]
+`,
+			expect: []ConfigError{
+				{Line: 3, Column: 1, Message: "unknown field `appendonly`"},
+				{Line: 6, Column: 10, Message: "invalid `title`: error parsing regexp: missing closing ): `^WIP(:.*`"},
+				{Line: 7, Column: 11, Message: "`negate` must be true or false, got \"maybe\""},
+				{Line: 10, Column: 15, Message: "invalid `last-modified.at-least`: time: unknown unit \" weeks\" in duration \"2 weeks\""},
+				{Line: 11, Column: 9, Message: "invalid `type`: must be `pull_request` or `issue`"},
+				{Line: 13, Column: 10, Message: "expected a list or a mapping for `files`"},
+				{Line: 15, Column: 12, Message: "invalid `draft`: strconv.ParseBool: parsing \"sure\": invalid syntax"},
+				{Line: 16, Column: 5, Message: "unknown field `authorz`"},
+			},
+		},
+		{
+			name: "Missing version",
+			config: `
+labels:
+- label: "WIP"
+  title: "^WIP:.*"
+`,
+			expect: []ConfigError{
+				{Line: 2, Column: 1, Message: "missing `version: 1`, the config would be parsed with the legacy format"},
+			},
+		},
+		{
+			name:   "Unsupported version",
+			config: "version: 2\nlabels: []\n",
+			expect: []ConfigError{
+				{Line: 1, Column: 10, Message: "unsupported version \"2\", expected 1"},
+			},
+		},
+		{
+			name:   "Invalid yaml",
+			config: "version: 1\nlabels:\n- label: [\n",
+			expect: []ConfigError{
+				{Line: 3, Message: "yaml: line 3: did not find expected node content"},
+			},
+		},
+		{
+			name: "Invalid managed labels",
+			config: `
+version: 1
+managed-labels: ["size/*", "regex:area/(.+", "[size"]
+labels:
+- label: "WIP"
+  title: "^WIP:.*"
+`,
+			expect: []ConfigError{
+				{Line: 3, Column: 28, Message: "invalid `managed-labels`: error parsing regexp: missing closing ): `^(?:area/(.+)$`"},
+				{Line: 3, Column: 46, Message: "invalid `managed-labels`: syntax error in pattern"},
+			},
+		},
+		{
+			name: "Invalid group strategy",
+			config: `
+version: 1
+groups:
+- name: size
+  labels: ["size/S", "size/L"]
+  strategy: biggest
+labels:
+- label: "size/S"
+  size-below: 10
+`,
+			expect: []ConfigError{
+				{Line: 6, Column: 13, Message: "invalid `groups.strategy`: must be `first`, `last` or `priority`"},
+			},
+		},
+		{
+			name: "Invalid checks",
+			config: `
+version: 1
+labels:
+- label: "ci-green"
+  checks:
+    state: green
+    names: ["build", "lint("]
+`,
+			expect: []ConfigError{
+				{Line: 6, Column: 12, Message: "invalid `checks.state`: must be `success`, `failure` or `pending`"},
+				{Line: 7, Column: 22, Message: "invalid `checks.names`: error parsing regexp: missing closing ): `lint(`"},
+			},
+		},
+		{
+			name: "Invalid linked issues",
+			config: `
+version: 1
+labels:
+- linked-issues:
+    copy-labels: ["area/*", "[priority"]
+    state: merged
+`,
+			expect: []ConfigError{
+				{Line: 5, Column: 29, Message: "invalid `linked-issues.copy-labels`: syntax error in pattern"},
+				{Line: 6, Column: 12, Message: "invalid `linked-issues.state`: must be `open` or `closed`"},
+			},
+		},
+		{
+			name: "Generator settings in matchers with a label",
+			config: `
+version: 1
+labels:
+- label: "docs"
+  linked-issues:
+    copy-labels: ["area/*"]
+- label: "payments"
+  codeowners:
+    owners: ["@org/payments"]
+    label-prefix: "team/"
+- codeowners:
+    label-prefix: "team/"
+  linked-issues:
+    copy-labels: ["area/*"]
+`,
+			expect: []ConfigError{
+				{Line: 6, Column: 5, Message: "`linked-issues.copy-labels` is ignored in matchers with a `label`"},
+				{Line: 10, Column: 5, Message: "`codeowners.label-prefix` is ignored in matchers with a `label`"},
+			},
+		},
+		{
+			name: "Assignees as a list or an object",
+			config: `
+version: 1
+labels:
+- label: "alice"
+  assignees: ["alice"]
+- label: "backend"
+  assignees:
+    team: backend
+    none: ["bob"]
+- label: "bad"
+  assignees: "alice"
+  has-assignee: maybe
+`,
+			expect: []ConfigError{
+				{Line: 11, Column: 14, Message: "expected a list or a mapping for `assignees`"},
+				{Line: 12, Column: 17, Message: "invalid `has-assignee`: strconv.ParseBool: parsing \"maybe\": invalid syntax"},
+			},
+		},
+		{
+			name: "Invalid labels condition",
+			config: `
+version: 1
+labels:
+- label: "needs-triage"
+  labels:
+    has-none: ["area/*", "regex:(kind"]
+    has-some: ["bug"]
+`,
+			expect: []ConfigError{
+				{Line: 6, Column: 26, Message: "invalid `labels.has-none`: error parsing regexp: missing closing ): `^(?:(kind)$`"},
+				{Line: 7, Column: 5, Message: "unknown field `has-some`"},
+			},
+		},
+		{
+			name: "Invalid file globs",
+			config: `
+version: 1
+labels:
+- label: "docs"
+  files: ["glob:docs/**", "glob:[abc"]
+  size:
+    exclude-files: ["glob:!"]
+`,
+			expect: []ConfigError{
+				{Line: 5, Column: 27, Message: "invalid `files`: unclosed `[` in glob"},
+				{Line: 7, Column: 21, Message: "invalid `size.exclude-files`: empty glob"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ValidateConfig([]byte(tc.config))
+			if len(tc.expect) == 0 && len(got) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(tc.expect, got) {
+				t.Fatalf("\nExpect: %+v\nGot: %+v", tc.expect, got)
+			}
+		})
+	}
+}